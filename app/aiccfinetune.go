@@ -1,14 +1,23 @@
 package app
 
 import (
+	"encoding/json"
 	"errors"
+	"path"
+	"time"
 
 	"github.com/opensourceways/xihe-aicc-finetune/domain"
 	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
 	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
 	"github.com/sirupsen/logrus"
 )
 
+// maxScheduledRetryTime is the time in which the scheduled finetune
+// failed to be submitted will be submitted again, since the error may
+// be transient. It waits for the capacity without limit.
+const maxScheduledRetryTime = time.Hour
+
 type AICCFinetuneCreateCmd struct {
 	FinetuneId string
 
@@ -20,12 +29,41 @@ func (cmd *AICCFinetuneCreateCmd) Validate() error {
 
 	b := cmd.User != nil &&
 		cmd.Name != nil &&
+		cmd.Model != nil &&
+		cmd.Task != nil &&
 		cmd.FinetuneId != ""
 
 	if !b {
 		return err
 	}
 
+	kind := cmd.Task.Kind()
+
+	if !kind.SupportModel(cmd.Model.ModelName()) {
+		return errors.New("the task does not support the model")
+	}
+
+	if kind.NeedInput(domain.InputSourceCheckpoint) && cmd.Parent == "" {
+		return errors.New("the task needs a finetune to provide the checkpoint")
+	}
+
+	if cmd.Parent != "" && !kind.HasInput(domain.InputSourceCheckpoint) {
+		return errors.New("the task can't use the checkpoint of finetune")
+	}
+
+	if cmd.Parent != "" && cmd.Task.TaskType() == domain.TaskFinetune && !cmd.Resume {
+		return errors.New("finetune can only use the checkpoint by resuming")
+	}
+
+	if cmd.Inference != nil && !kind.AcceptPrompts {
+		return errors.New("the task does not accept prompts")
+	}
+
+	if kind.NeedInput(domain.InputSourceEvalSet) &&
+		(cmd.EvalSet == nil || cmd.EvalSet.Directory() == "") {
+		return errors.New("the task needs an evaluation set")
+	}
+
 	f := func(kv []domain.KeyValue) error {
 		for i := range kv {
 			if kv[i].Key == nil {
@@ -47,10 +85,265 @@ func (cmd *AICCFinetuneCreateCmd) Validate() error {
 	return nil
 }
 
+type AICCFinetuneResumeCmd struct {
+	// FinetuneId is the id of the new finetune.
+	FinetuneId string
+
+	// Original is the id of finetune to be resumed.
+	Original string
+}
+
+func (cmd *AICCFinetuneResumeCmd) Validate() error {
+	if cmd.FinetuneId == "" || cmd.Original == "" {
+		return errors.New("invalid cmd of resuming aicc finetune")
+	}
+
+	return nil
+}
+
+// AICCFinetuneCloneCmd clones the config of finetune. The fields
+// which are nil or empty mean to keep the original ones.
+type AICCFinetuneCloneCmd struct {
+	// FinetuneId is the id of the new finetune.
+	FinetuneId string
+
+	// Original is the id of finetune to be cloned.
+	Original string
+
+	Name domain.FinetuneName
+	Desc domain.FinetuneDesc
+
+	// Hyperparameters and Env override the original
+	// ones which have the same key.
+	Hyperparameters []domain.KeyValue
+	Env             []domain.KeyValue
+
+	Inference *domain.InferenceOption
+	Resource  *domain.JobResource
+	Limits    *domain.JobLimits
+}
+
+func (cmd *AICCFinetuneCloneCmd) Validate() error {
+	if cmd.FinetuneId == "" || cmd.Original == "" {
+		return errors.New("invalid cmd of cloning aicc finetune")
+	}
+
+	return nil
+}
+
+func (cmd *AICCFinetuneCloneCmd) toCreateCmd(t *domain.AICCFinetune) (AICCFinetuneCreateCmd, error) {
+	if cmd.Inference != nil && !t.Task.Kind().AcceptPrompts {
+		return AICCFinetuneCreateCmd{}, errors.New("the task does not accept prompts")
+	}
+
+	c := AICCFinetuneCreateCmd{
+		FinetuneId: cmd.FinetuneId,
+		AICCFinetune: domain.AICCFinetune{
+			Id:                 cmd.FinetuneId,
+			User:               t.User,
+			Model:              t.Model,
+			Task:               t.Task,
+			Parent:             t.Parent,
+			Checkpoint:         t.Checkpoint,
+			Resume:             t.Resume,
+			AICCFinetuneConfig: t.AICCFinetuneConfig,
+		},
+	}
+
+	if cmd.Name != nil {
+		c.Name = cmd.Name
+	}
+
+	if cmd.Desc != nil && cmd.Desc.FinetuneDesc() != "" {
+		c.Desc = cmd.Desc
+	}
+
+	c.Hyperparameters = mergeKeyValues(t.Hyperparameters, cmd.Hyperparameters)
+	c.Env = mergeKeyValues(t.Env, cmd.Env)
+
+	if cmd.Inference != nil {
+		c.Inference = cmd.Inference
+	}
+
+	if cmd.Resource != nil {
+		c.Resource = *cmd.Resource
+	}
+
+	if cmd.Limits != nil {
+		c.Limits = *cmd.Limits
+	}
+
+	if err := c.Validate(); err != nil {
+		return AICCFinetuneCreateCmd{}, err
+	}
+
+	return c, nil
+}
+
+// mergeKeyValues returns the key values of base which are
+// replaced or appended by the ones of override.
+func mergeKeyValues(base, override []domain.KeyValue) []domain.KeyValue {
+	if len(override) == 0 {
+		return base
+	}
+
+	r := make([]domain.KeyValue, 0, len(base)+len(override))
+	index := make(map[string]int, len(base))
+
+	for i := range base {
+		index[base[i].Key.CustomizedKey()] = len(r)
+		r = append(r, base[i])
+	}
+
+	for i := range override {
+		if j, ok := index[override[i].Key.CustomizedKey()]; ok {
+			r[j] = override[i]
+		} else {
+			r = append(r, override[i])
+		}
+	}
+
+	return r
+}
+
 type JobInfoDTO = domain.JobInfo
 
+type JobAttemptDTO struct {
+	JobId     string `json:"job_id"`
+	Status    string `json:"status"`
+	Duration  int    `json:"duration"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type ProgressDTO struct {
+	Step        int     `json:"step"`
+	TotalSteps  int     `json:"total_steps"`
+	Percent     int     `json:"percent"`
+	Epoch       int     `json:"epoch,omitempty"`
+	TotalEpochs int     `json:"total_epochs,omitempty"`
+	Loss        float64 `json:"loss"`
+	ETA         int     `json:"eta"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+func toProgressDTO(p *domain.Progress) ProgressDTO {
+	return ProgressDTO{
+		Step:        p.Step,
+		TotalSteps:  p.TotalSteps,
+		Percent:     p.Percent(),
+		Epoch:       p.Epoch,
+		TotalEpochs: p.TotalEpochs,
+		Loss:        p.Loss,
+		ETA:         p.ETA,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+type InferenceImageDTO struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+type AICCFinetuneDTO struct {
+	Id         string         `json:"id"`
+	User       string         `json:"user"`
+	Model      string         `json:"model"`
+	Task       string         `json:"task"`
+	Parent     string         `json:"parent,omitempty"`
+	Resume     bool           `json:"resume,omitempty"`
+	Children   []string       `json:"children,omitempty"`
+	SubmitAt   int64          `json:"submit_at,omitempty"`
+	Pipeline   string         `json:"pipeline,omitempty"`
+	Name       string         `json:"name"`
+	Flavor     string         `json:"flavor,omitempty"`
+	NodeCount  int            `json:"node_count"`
+	Priority   string         `json:"priority_class,omitempty"`
+	JobId      string         `json:"job_id"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	Category   string         `json:"error_category,omitempty"`
+	Reason     string         `json:"termination_reason,omitempty"`
+	Duration   int            `json:"duration"`
+	LogPath    string         `json:"log_path,omitempty"`
+	OutputPath string         `json:"output_path,omitempty"`
+	Metrics    domain.Metrics `json:"metrics,omitempty"`
+
+	Attempts []JobAttemptDTO `json:"attempts,omitempty"`
+
+	// Progress is the latest training progress, and ProgressHistory
+	// is the recent ones which can be used to draw the loss curve.
+	Progress        *ProgressDTO  `json:"progress,omitempty"`
+	ProgressHistory []ProgressDTO `json:"progress_history,omitempty"`
+}
+
+func toAICCFinetuneDTO(t *domain.AICCFinetune) AICCFinetuneDTO {
+	dto := AICCFinetuneDTO{
+		Id:         t.Id,
+		User:       t.User.Account(),
+		Model:      t.Model.ModelName(),
+		Task:       t.Task.TaskType(),
+		Parent:     t.Parent,
+		Resume:     t.Resume,
+		SubmitAt:   t.Schedule.SubmitAt,
+		Pipeline:   t.Pipeline,
+		Children:   t.Children,
+		Name:       t.Name.FinetuneName(),
+		Flavor:     t.Resource.Flavor,
+		NodeCount:  t.Resource.NodeCount,
+		Priority:   t.PriorityClass,
+		JobId:      t.Job.JobId,
+		Error:      t.JobDetail.Error,
+		Category:   t.JobDetail.ErrorCategory,
+		Reason:     t.JobDetail.TerminationReason,
+		Duration:   t.JobDetail.Duration,
+		LogPath:    t.JobDetail.LogPath,
+		OutputPath: t.JobDetail.OutputPath,
+		Metrics:    t.JobDetail.Metrics,
+	}
+
+	if t.JobDetail.Status != nil {
+		dto.Status = t.JobDetail.Status.TrainingStatus()
+	}
+
+	if n := len(t.JobDetail.Attempts); n > 0 {
+		dto.Attempts = make([]JobAttemptDTO, n)
+		for i, v := range t.JobDetail.Attempts {
+			dto.Attempts[i] = JobAttemptDTO{
+				JobId:     v.JobId,
+				Status:    v.Status.TrainingStatus(),
+				Duration:  v.Duration,
+				CreatedAt: v.CreatedAt,
+			}
+		}
+	}
+
+	if p := &t.JobDetail.Progress; !p.IsEmpty() {
+		v := toProgressDTO(p)
+		dto.Progress = &v
+	}
+
+	if n := len(t.JobDetail.ProgressHistory); n > 0 {
+		dto.ProgressHistory = make([]ProgressDTO, n)
+		for i := range t.JobDetail.ProgressHistory {
+			dto.ProgressHistory[i] = toProgressDTO(&t.JobDetail.ProgressHistory[i])
+		}
+	}
+
+	return dto
+}
+
+// JobPayloadDTO is the payload of creating aicc job.
+type JobPayloadDTO = json.RawMessage
+
 type FinetuneService interface {
 	Create(cmd *AICCFinetuneCreateCmd) (JobInfoDTO, error)
+	Render(cmd *AICCFinetuneCreateCmd) (JobPayloadDTO, error)
+	Resume(cmd *AICCFinetuneResumeCmd) (JobInfoDTO, error)
+	Clone(cmd *AICCFinetuneCloneCmd) (JobInfoDTO, error)
+	SubmitScheduled(finetuneId string) error
+	Get(finetuneId string) (AICCFinetuneDTO, error)
+	GetImages(finetuneId string) ([]InferenceImageDTO, error)
 	Delete(jobId string) error
 	Terminate(jobId string) error
 	GetLogDownloadURL(jobId string) (string, error)
@@ -60,22 +353,203 @@ type FinetuneService interface {
 func NewAICCFinetuneService(
 	ts aiccfinetune.AICCFinetune,
 	ws watch.WatchService,
+	repo repository.AICCFinetune,
 	log *logrus.Entry,
 ) FinetuneService {
 	return &aiccFinetuneService{
-		ts:  ts,
-		ws:  ws,
-		log: log,
+		ts:   ts,
+		ws:   ws,
+		repo: repo,
+		log:  log,
 	}
 }
 
 type aiccFinetuneService struct {
-	log *logrus.Entry
-	ts  aiccfinetune.AICCFinetune
-	ws  watch.WatchService
+	log  *logrus.Entry
+	ts   aiccfinetune.AICCFinetune
+	ws   watch.WatchService
+	repo repository.AICCFinetune
 }
 
 func (s *aiccFinetuneService) Create(cmd *AICCFinetuneCreateCmd) (JobInfoDTO, error) {
+	cmd.Id = cmd.FinetuneId
+
+	if err := s.resolveParent(cmd); err != nil {
+		return JobInfoDTO{}, err
+	}
+
+	if cmd.Schedule.IsScheduled() {
+		return JobInfoDTO{}, s.schedule(cmd)
+	}
+
+	return s.submit(cmd)
+}
+
+// schedule saves the finetune which will be submitted by the scheduler.
+func (s *aiccFinetuneService) schedule(cmd *AICCFinetuneCreateCmd) error {
+	if err := s.checkNotExists(cmd.FinetuneId); err != nil {
+		return err
+	}
+
+	cmd.JobDetail = domain.JobDetail{Status: domain.TrainingStatusScheduled}
+
+	if err := s.repo.Save(&cmd.AICCFinetune); err != nil {
+		return err
+	}
+
+	return s.repo.AddScheduled(cmd.FinetuneId, cmd.Schedule.SubmitAt)
+}
+
+// SubmitScheduled submits the scheduled finetune. The finetune is kept
+// scheduled if there is no capacity or the error may be transient, and
+// it will be marked as failed if failed to create the job otherwise.
+func (s *aiccFinetuneService) SubmitScheduled(finetuneId string) error {
+	v, err := s.repo.Get(finetuneId)
+	if err != nil {
+		if repository.IsErrorResourceNotExists(err) {
+			return s.repo.RemoveScheduled(finetuneId)
+		}
+
+		return err
+	}
+
+	if v.JobDetail.Status != domain.TrainingStatusScheduled {
+		return s.repo.RemoveScheduled(finetuneId)
+	}
+
+	if s.ws.Available() <= 0 {
+		return errors.New("exceed max watch num, it will be submitted later")
+	}
+
+	cmd := AICCFinetuneCreateCmd{
+		FinetuneId:   finetuneId,
+		AICCFinetune: v,
+	}
+	cmd.JobDetail = domain.JobDetail{}
+
+	if _, err := s.apply(&cmd); err != nil {
+		if isScheduledRetryable(&v, err) {
+			return err
+		}
+
+		detail := domain.JobDetail{
+			Status: domain.TrainingStatusFailed,
+			Error:  err.Error(),
+		}
+
+		if err := s.repo.UpdateDetail(finetuneId, &detail); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.RemoveScheduled(finetuneId)
+}
+
+func isScheduledRetryable(f *domain.AICCFinetune, err error) bool {
+	if watch.IsErrorExceedMaxWatchNum(err) {
+		return true
+	}
+
+	return !IsErrorBadRequest(err) &&
+		time.Since(time.Unix(f.Schedule.SubmitAt, 0)) < maxScheduledRetryTime
+}
+
+// Resume creates a finetune which continues the training from the latest
+// checkpoint of the finetune which was terminated or failed.
+func (s *aiccFinetuneService) Resume(cmd *AICCFinetuneResumeCmd) (dto JobInfoDTO, err error) {
+	v, err := s.repo.Get(cmd.Original)
+	if err != nil {
+		return
+	}
+
+	if v.Task.TaskType() != domain.TaskFinetune {
+		err = newErrorBadRequest(errors.New("only finetune can be resumed"))
+
+		return
+	}
+
+	if st := v.JobDetail.Status; st == nil || !st.IsDone() || st.IsSuccess() {
+		err = newErrorBadRequest(
+			errors.New("only the terminated or failed finetune can be resumed"),
+		)
+
+		return
+	}
+
+	ckpt, err := s.ts.FindLatestCheckpoint(v.Job.OutputDir)
+	if err != nil {
+		return
+	}
+
+	if ckpt == "" {
+		err = newErrorBadRequest(errors.New("no checkpoint found"))
+
+		return
+	}
+
+	c := AICCFinetuneCreateCmd{
+		FinetuneId: cmd.FinetuneId,
+		AICCFinetune: domain.AICCFinetune{
+			Id:                 cmd.FinetuneId,
+			User:               v.User,
+			Model:              v.Model,
+			Task:               v.Task,
+			Parent:             v.Id,
+			Checkpoint:         ckpt,
+			Resume:             true,
+			AICCFinetuneConfig: v.AICCFinetuneConfig,
+		},
+	}
+
+	if err = c.Validate(); err != nil {
+		err = newErrorBadRequest(err)
+
+		return
+	}
+
+	return s.submit(&c)
+}
+
+// Clone creates a finetune with the config of an existing one
+// and the overrides of cmd.
+func (s *aiccFinetuneService) Clone(cmd *AICCFinetuneCloneCmd) (JobInfoDTO, error) {
+	v, err := s.repo.Get(cmd.Original)
+	if err != nil {
+		return JobInfoDTO{}, err
+	}
+
+	c, err := cmd.toCreateCmd(&v)
+	if err != nil {
+		return JobInfoDTO{}, newErrorBadRequest(err)
+	}
+
+	return s.submit(&c)
+}
+
+func (s *aiccFinetuneService) checkNotExists(finetuneId string) error {
+	_, err := s.repo.Get(finetuneId)
+	if err == nil {
+		return newErrorBadRequest(errors.New("the finetune exists"))
+	}
+
+	if repository.IsErrorResourceNotExists(err) {
+		return nil
+	}
+
+	return err
+}
+
+// submit creates the job of a new finetune and watches it.
+func (s *aiccFinetuneService) submit(cmd *AICCFinetuneCreateCmd) (JobInfoDTO, error) {
+	if err := s.checkNotExists(cmd.FinetuneId); err != nil {
+		return JobInfoDTO{}, err
+	}
+
+	return s.apply(cmd)
+}
+
+// apply creates the job of finetune and watches it.
+func (s *aiccFinetuneService) apply(cmd *AICCFinetuneCreateCmd) (JobInfoDTO, error) {
 	dto := JobInfoDTO{}
 
 	f := func(info *watch.FinetuneInfo) error {
@@ -87,6 +561,8 @@ func (s *aiccFinetuneService) Create(cmd *AICCFinetuneCreateCmd) (JobInfoDTO, er
 		dto = v
 		*info = watch.FinetuneInfo{
 			User:       cmd.User,
+			Model:      cmd.Model,
+			Task:       cmd.Task,
 			FinetuneId: cmd.FinetuneId,
 			JobInfo:    v,
 		}
@@ -94,16 +570,108 @@ func (s *aiccFinetuneService) Create(cmd *AICCFinetuneCreateCmd) (JobInfoDTO, er
 		return nil
 	}
 
-	err := s.ws.ApplyWatch(f)
+	a := watch.Admission{
+		FinetuneId:    cmd.FinetuneId,
+		User:          cmd.User,
+		Task:          cmd.Task,
+		PriorityClass: cmd.PriorityClass,
+	}
+
+	err := s.ws.ApplyWatch(&a, f)
 
 	return dto, err
 }
 
 func (s *aiccFinetuneService) create(cmd *AICCFinetuneCreateCmd) (info domain.JobInfo, err error) {
-	if cmd.Task == "finetune" {
-		return s.ts.Create(&cmd.AICCFinetune)
+	if info, err = s.ts.Create(&cmd.AICCFinetune); err != nil {
+		err = toBadRequest(err)
+
+		return
+	}
+
+	cmd.Job = info
+
+	// the job has been created, so it should be watched
+	// even if failed to save it.
+	if err := s.repo.Save(&cmd.AICCFinetune); err != nil {
+		s.log.Errorf(
+			"save finetune(%s) failed, err:%s",
+			cmd.FinetuneId, err.Error(),
+		)
+	}
+
+	if cmd.Parent != "" {
+		if err := s.repo.AddChild(cmd.Parent, cmd.FinetuneId); err != nil {
+			s.log.Errorf(
+				"add child(%s) to finetune(%s) failed, err:%s",
+				cmd.FinetuneId, cmd.Parent, err.Error(),
+			)
+		}
+	}
+
+	return
+}
+
+// resolveParent sets the checkpoint of cmd to the output of parent
+// which must be a finetune of the same user and completed successfully.
+func (s *aiccFinetuneService) resolveParent(cmd *AICCFinetuneCreateCmd) error {
+	if cmd.Parent == "" {
+		return nil
+	}
+
+	p, err := s.repo.Get(cmd.Parent)
+	if err != nil {
+		return err
 	}
-	return s.ts.CreateInference(&cmd.AICCFinetune)
+
+	if p.User.Account() != cmd.User.Account() ||
+		p.Model.ModelName() != cmd.Model.ModelName() ||
+		p.Task.TaskType() != domain.TaskFinetune {
+		return newErrorBadRequest(
+			errors.New("the parent is not a finetune of the same user and model"),
+		)
+	}
+
+	if p.JobDetail.Status == nil || !p.JobDetail.Status.IsSuccess() {
+		return newErrorBadRequest(errors.New("the parent has not completed successfully"))
+	}
+
+	cmd.Checkpoint = p.Job.OutputDir
+
+	return nil
+}
+
+func (s *aiccFinetuneService) Render(cmd *AICCFinetuneCreateCmd) (JobPayloadDTO, error) {
+	cmd.Id = cmd.FinetuneId
+
+	if err := s.resolveParent(cmd); err != nil {
+		return nil, err
+	}
+
+	v, err := s.ts.Render(&cmd.AICCFinetune)
+
+	return v, toBadRequest(err)
+}
+
+// toBadRequest converts the error of job which is not allowed
+// by the config, so the user can fix it by changing the request.
+func toBadRequest(err error) error {
+	if aiccfinetune.IsErrorNotAllowed(err) {
+		return newErrorBadRequest(err)
+	}
+
+	return err
+}
+
+func (s *aiccFinetuneService) Get(finetuneId string) (dto AICCFinetuneDTO, err error) {
+	v, err := s.repo.Get(finetuneId)
+	if err != nil {
+		return
+	}
+
+	dto = toAICCFinetuneDTO(&v)
+
+	return
 }
 
 func (s *aiccFinetuneService) Terminate(jobId string) error {
@@ -121,3 +689,32 @@ func (s *aiccFinetuneService) GenFileDownloadURL(obsfile string) (string, error)
 func (s *aiccFinetuneService) Delete(jobId string) error {
 	return s.ts.Delete(jobId)
 }
+
+func (s *aiccFinetuneService) GetImages(finetuneId string) ([]InferenceImageDTO, error) {
+	v, err := s.repo.Get(finetuneId)
+	if err != nil {
+		return nil, err
+	}
+
+	images := v.JobDetail.Images
+	r := make([]InferenceImageDTO, len(images))
+
+	for i := range images {
+		item := &images[i]
+
+		if r[i].URL, err = s.ts.GenFileDownloadURL(item.Path); err != nil {
+			return nil, err
+		}
+
+		// the thumbnail is empty if the image can't be decoded.
+		if item.Thumbnail == "" {
+			r[i].ThumbnailURL = r[i].URL
+		} else if r[i].ThumbnailURL, err = s.ts.GenFileDownloadURL(item.Thumbnail); err != nil {
+			return nil, err
+		}
+
+		r[i].Name = path.Base(item.Path)
+	}
+
+	return r, nil
+}
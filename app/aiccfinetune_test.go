@@ -0,0 +1,164 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+// fakeFinetuneRepo keeps the finetunes in memory. The methods
+// not implemented panic by the nil embedded interface.
+type fakeFinetuneRepo struct {
+	repository.AICCFinetune
+
+	finetunes map[string]domain.AICCFinetune
+	scheduled map[string]bool
+}
+
+func (r *fakeFinetuneRepo) Get(finetuneId string) (domain.AICCFinetune, error) {
+	v, ok := r.finetunes[finetuneId]
+	if !ok {
+		return v, repository.NewErrorResourceNotExists(errors.New("not found"))
+	}
+
+	return v, nil
+}
+
+func (r *fakeFinetuneRepo) UpdateDetail(finetuneId string, detail *domain.JobDetail) error {
+	v := r.finetunes[finetuneId]
+	v.JobDetail = *detail
+	r.finetunes[finetuneId] = v
+
+	return nil
+}
+
+func (r *fakeFinetuneRepo) RemoveScheduled(finetuneId string) error {
+	delete(r.scheduled, finetuneId)
+
+	return nil
+}
+
+// fakeWatchService fails to apply watching with err.
+type fakeWatchService struct {
+	watch.WatchService
+
+	err error
+}
+
+func (ws fakeWatchService) ApplyWatch(a *watch.Admission, f func(*watch.FinetuneInfo) error) error {
+	return ws.err
+}
+
+func (ws fakeWatchService) Available() int {
+	return 1
+}
+
+// fakeAICCFinetune signs the url by prefixing the path.
+type fakeAICCFinetune struct {
+	aiccfinetune.AICCFinetune
+}
+
+func (f fakeAICCFinetune) GenFileDownloadURL(p string) (string, error) {
+	if p == "" {
+		return "", errors.New("empty obs path")
+	}
+
+	return "https://obs/" + p, nil
+}
+
+func TestGetImagesWithoutThumbnail(t *testing.T) {
+	repo := &fakeFinetuneRepo{
+		finetunes: map[string]domain.AICCFinetune{
+			"1": {JobDetail: domain.JobDetail{
+				Images: []domain.InferenceImage{
+					{Path: "output/a.png", Thumbnail: "output/thumbnails/a.png"},
+					// the image failed to decode has no thumbnail.
+					{Path: "output/b.png"},
+				},
+			}},
+		},
+	}
+
+	s := aiccFinetuneService{ts: fakeAICCFinetune{}, repo: repo}
+
+	v, err := s.GetImages("1")
+	if err != nil {
+		t.Fatalf("get images failed, err:%s", err.Error())
+	}
+
+	want := []InferenceImageDTO{
+		{Name: "a.png", URL: "https://obs/output/a.png", ThumbnailURL: "https://obs/output/thumbnails/a.png"},
+		{Name: "b.png", URL: "https://obs/output/b.png", ThumbnailURL: "https://obs/output/b.png"},
+	}
+
+	if len(v) != len(want) {
+		t.Fatalf("got %d images, want %d", len(v), len(want))
+	}
+
+	for i := range want {
+		if v[i] != want[i] {
+			t.Errorf("got %+v, want %+v", v[i], want[i])
+		}
+	}
+}
+
+func TestSubmitScheduledFailed(t *testing.T) {
+	now := time.Now().Unix()
+	overdue := now - int64(2*maxScheduledRetryTime/time.Second)
+
+	cases := []struct {
+		name      string
+		submitAt  int64
+		err       error
+		scheduled bool
+	}{
+		{"no capacity", now, watch.NewErrorExceedMaxWatchNum(), true},
+		{"no capacity for a long time", overdue, watch.NewErrorExceedMaxWatchNum(), true},
+		{"transient error", now, errors.New("connection reset"), true},
+		{"transient error for a long time", overdue, errors.New("connection reset"), false},
+		{"bad request", now, newErrorBadRequest(errors.New("invalid")), false},
+	}
+
+	for i := range cases {
+		c := &cases[i]
+
+		t.Run(c.name, func(t *testing.T) {
+			repo := &fakeFinetuneRepo{
+				finetunes: map[string]domain.AICCFinetune{
+					"1": {
+						Schedule:  domain.JobSchedule{SubmitAt: c.submitAt},
+						JobDetail: domain.JobDetail{Status: domain.TrainingStatusScheduled},
+					},
+				},
+				scheduled: map[string]bool{"1": true},
+			}
+
+			s := aiccFinetuneService{ws: fakeWatchService{err: c.err}, repo: repo}
+
+			err := s.SubmitScheduled("1")
+
+			if repo.scheduled["1"] != c.scheduled {
+				t.Fatalf("got scheduled %t, want %t", repo.scheduled["1"], c.scheduled)
+			}
+
+			st := repo.finetunes["1"].JobDetail.Status
+
+			if c.scheduled {
+				if err == nil {
+					t.Error("expect error to submit it later")
+				}
+
+				if st != domain.TrainingStatusScheduled {
+					t.Errorf("got status %s, want scheduled", st.TrainingStatus())
+				}
+			} else if st != domain.TrainingStatusFailed {
+				t.Errorf("got status %s, want failed", st.TrainingStatus())
+			}
+		})
+	}
+}
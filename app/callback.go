@@ -0,0 +1,109 @@
+package app
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+type CallbackEventDTO struct {
+	Id            string `json:"id"`
+	FinetuneId    string `json:"finetune_id"`
+	User          string `json:"user"`
+	Status        string `json:"status"`
+	Duration      int    `json:"duration"`
+	LogPath       string `json:"log_path,omitempty"`
+	OutputZipPath string `json:"output_zip_path,omitempty"`
+	Final         bool   `json:"final"`
+	Attempts      int    `json:"attempts"`
+	NextAttemptAt int64  `json:"next_attempt_at"`
+	LastError     string `json:"last_error,omitempty"`
+	Dead          bool   `json:"dead"`
+	Superseded    bool   `json:"superseded,omitempty"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+func toCallbackEventDTO(e *watch.CallbackEvent) CallbackEventDTO {
+	return CallbackEventDTO{
+		Id:            e.Id,
+		FinetuneId:    e.FinetuneId,
+		User:          e.User,
+		Status:        e.Status,
+		Duration:      e.Duration,
+		LogPath:       e.LogPath,
+		OutputZipPath: e.OutputZipPath,
+		Final:         e.Final,
+		Attempts:      e.Attempts,
+		NextAttemptAt: e.NextAttemptAt,
+		LastError:     e.LastError,
+		Dead:          e.Dead,
+		Superseded:    e.Superseded,
+		CreatedAt:     e.CreatedAt,
+	}
+}
+
+// CallbackService inspects and replays the callback events
+// which are waiting to be delivered to the xihe server.
+type CallbackService interface {
+	// List returns the events in order. Only the dead ones
+	// are returned if dead is true.
+	List(dead bool) ([]CallbackEventDTO, error)
+
+	// Replay delivers the dead event again. It is delivered before
+	// the later events of the same finetune, and it can't be replayed
+	// if a later one has been delivered.
+	Replay(eventId string) error
+}
+
+func NewCallbackService(repo repository.CallbackOutbox) CallbackService {
+	return callbackService{repo}
+}
+
+type callbackService struct {
+	repo repository.CallbackOutbox
+}
+
+func (s callbackService) List(dead bool) ([]CallbackEventDTO, error) {
+	events, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Seq < events[j].Seq
+	})
+
+	r := make([]CallbackEventDTO, 0, len(events))
+	for i := range events {
+		if !dead || events[i].Dead {
+			r = append(r, toCallbackEventDTO(&events[i]))
+		}
+	}
+
+	return r, nil
+}
+
+func (s callbackService) Replay(eventId string) error {
+	e, err := s.repo.Get(eventId)
+	if err != nil {
+		return err
+	}
+
+	if !e.Dead {
+		return newErrorBadRequest(errors.New("only the dead event can be replayed"))
+	}
+
+	if e.Superseded {
+		return newErrorBadRequest(errors.New(
+			"a later event of the finetune has been delivered",
+		))
+	}
+
+	e.Dead = false
+	e.Attempts = 0
+	e.NextAttemptAt = 0
+
+	return s.repo.Save(&e)
+}
@@ -0,0 +1,18 @@
+package app
+
+// errorBadRequest means the request can't be done in the current state,
+// such as the resource exists or there is no capacity, so retrying the
+// same request will not help until the state changes.
+type errorBadRequest struct {
+	error
+}
+
+func newErrorBadRequest(err error) errorBadRequest {
+	return errorBadRequest{err}
+}
+
+func IsErrorBadRequest(err error) bool {
+	_, ok := err.(errorBadRequest)
+
+	return ok
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+const leaseWatcher = "watcher"
+
+func NewLeaderElector(
+	lease repository.Lease,
+	holder string,
+	duration time.Duration,
+	renewInterval time.Duration,
+	log *logrus.Entry,
+) *LeaderElector {
+	return &LeaderElector{
+		log:           log,
+		lease:         lease,
+		holder:        holder,
+		duration:      duration,
+		renewInterval: renewInterval,
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// LeaderElector elects one of the replicas as the leader by the lease,
+// so that only the leader watches the finetunes, while all the replicas
+// serve the api.
+type LeaderElector struct {
+	log           *logrus.Entry
+	lease         repository.Lease
+	holder        string
+	duration      time.Duration
+	renewInterval time.Duration
+
+	leading bool
+	// renewedAt is the time when the lease was renewed successfully.
+	renewedAt time.Time
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// Run tries to acquire the lease and renews it periodically. onStarted
+// is called in a new goroutine once it becomes the leader. onLost is
+// called if it failed to renew the lease before the lease expires, and
+// the replica should exit since the work of leader can't be handed off.
+func (e *LeaderElector) Run(onStarted, onLost func()) {
+	ticker := time.NewTicker(e.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		if e.tryLead() {
+			go onStarted()
+		}
+
+		if e.lost() {
+			e.leading = false
+
+			onLost()
+		}
+
+		select {
+		case <-ticker.C:
+
+		case <-e.stop:
+			close(e.stopped)
+
+			return
+		}
+	}
+}
+
+// tryLead returns true if it becomes the leader just now.
+func (e *LeaderElector) tryLead() bool {
+	now := time.Now()
+
+	ok, err := e.lease.TryAcquire(leaseWatcher, e.holder, e.duration)
+	if err != nil {
+		e.log.Errorf("acquire the lease failed, err:%s", err.Error())
+
+		return false
+	}
+
+	if !ok {
+		if e.leading {
+			// the lease was taken by another replica.
+			e.renewedAt = time.Time{}
+		}
+
+		return false
+	}
+
+	e.renewedAt = now
+
+	if e.leading {
+		return false
+	}
+
+	e.leading = true
+	e.log.Infof("%s becomes the leader", e.holder)
+
+	return true
+}
+
+func (e *LeaderElector) lost() bool {
+	return e.leading && time.Since(e.renewedAt) >= e.duration
+}
+
+// Exit stops renewing the lease and releases it if it is the
+// leader, so that another replica can become the leader soon.
+// It should be called after the work of leader is handed off.
+func (e *LeaderElector) Exit() {
+	close(e.stop)
+
+	<-e.stopped
+
+	if !e.leading {
+		return
+	}
+
+	if err := e.lease.Release(leaseWatcher, e.holder); err != nil {
+		e.log.Errorf("release the lease failed, err:%s", err.Error())
+	}
+}
@@ -0,0 +1,438 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+const (
+	pipelineStatusRunning   = "Running"
+	pipelineStatusFailed    = "Failed"
+	pipelineStatusCanceled  = "Canceled"
+	pipelineStatusCompleted = "Completed"
+
+	stepStatusWaiting = "Waiting"
+
+	// maxStepAttempts is the num of failures of creating the finetune
+	// of step before the step fails.
+	maxStepAttempts = 5
+)
+
+type PipelineCreateCmd struct {
+	domain.Pipeline
+}
+
+// Validate checks the steps and wires the output of finetune step as the
+// checkpoint of the step depending on it. The other shapes of dependency
+// are rejected, because the output of step can't be wired as the input.
+func (cmd *PipelineCreateCmd) Validate() error {
+	if cmd.Id == "" || cmd.User == nil || cmd.Model == nil {
+		return errors.New("invalid cmd of creating pipeline")
+	}
+
+	if err := domain.ValidatePipelineSteps(cmd.Steps); err != nil {
+		return err
+	}
+
+	for i := range cmd.Steps {
+		step := &cmd.Steps[i]
+
+		f := &step.Finetune
+		f.Id = domain.StepFinetuneId(cmd.Id, step.Name)
+		f.User = cmd.User
+		f.Model = cmd.Model
+		f.Pipeline = cmd.Id
+		f.Parent = ""
+
+		if len(step.DependsOn) > 0 {
+			if !cmd.canWire(step) {
+				return fmt.Errorf(
+					"step %s should only depend on a finetune step "+
+						"which provides the checkpoint for it", step.Name,
+				)
+			}
+
+			f.Parent = domain.StepFinetuneId(cmd.Id, step.DependsOn[0])
+		}
+
+		c := AICCFinetuneCreateCmd{FinetuneId: f.Id, AICCFinetune: *f}
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("invalid step %s, err:%s", step.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// canWire checks whether the step depends on one finetune step
+// whose output can be used as the checkpoint of the step.
+func (cmd *PipelineCreateCmd) canWire(step *domain.PipelineStep) bool {
+	if len(step.DependsOn) != 1 {
+		return false
+	}
+
+	t := step.Finetune.Task
+	dep := cmd.Step(step.DependsOn[0]).Finetune.Task
+
+	return t != nil && dep != nil &&
+		t.TaskType() != domain.TaskFinetune &&
+		t.Kind().HasInput(domain.InputSourceCheckpoint) &&
+		dep.TaskType() == domain.TaskFinetune
+}
+
+type PipelineStepDTO struct {
+	Name       string   `json:"name"`
+	Task       string   `json:"task"`
+	DependsOn  []string `json:"depends_on,omitempty"`
+	FinetuneId string   `json:"finetune_id"`
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type PipelineDTO struct {
+	Id        string            `json:"id"`
+	User      string            `json:"user"`
+	Model     string            `json:"model"`
+	Status    string            `json:"status"`
+	CreatedAt int64             `json:"created_at"`
+	Steps     []PipelineStepDTO `json:"steps"`
+}
+
+type PipelineService interface {
+	Create(cmd *PipelineCreateCmd) (PipelineDTO, error)
+	Get(pipelineId string) (PipelineDTO, error)
+	Cancel(pipelineId string) error
+
+	// OnFinetuneDone submits the steps which depend on the finetune.
+	OnFinetuneDone(finetuneId string)
+
+	// Resume advances the pipelines which have steps not submitted, such
+	// as the ones failed to be submitted or missed OnFinetuneDone.
+	Resume()
+}
+
+func NewPipelineService(
+	fs FinetuneService,
+	repo repository.Pipeline,
+	finetunes repository.AICCFinetune,
+	log *logrus.Entry,
+) PipelineService {
+	return &pipelineService{
+		fs:        fs,
+		log:       log,
+		repo:      repo,
+		finetunes: finetunes,
+	}
+}
+
+type pipelineService struct {
+	fs        FinetuneService
+	log       *logrus.Entry
+	repo      repository.Pipeline
+	finetunes repository.AICCFinetune
+
+	// lock serializes the changes of pipelines.
+	lock sync.Mutex
+}
+
+func (s *pipelineService) Create(cmd *PipelineCreateCmd) (dto PipelineDTO, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, err = s.repo.Get(cmd.Id); err == nil {
+		err = newErrorBadRequest(errors.New("the pipeline exists"))
+
+		return
+	}
+
+	if !repository.IsErrorResourceNotExists(err) {
+		return
+	}
+
+	cmd.CreatedAt = time.Now().Unix()
+
+	if err = s.repo.Save(&cmd.Pipeline); err != nil {
+		return
+	}
+
+	err1 := s.repo.Update(cmd.Id, func(p *domain.Pipeline) error {
+		s.advance(p)
+		cmd.Pipeline = *p
+
+		return nil
+	})
+	if err1 != nil {
+		s.log.Errorf("advance pipeline(%s) failed, err:%s", cmd.Id, err1.Error())
+	}
+
+	dto = s.toPipelineDTO(&cmd.Pipeline)
+
+	return
+}
+
+func (s *pipelineService) Get(pipelineId string) (dto PipelineDTO, err error) {
+	v, err := s.repo.Get(pipelineId)
+	if err != nil {
+		return
+	}
+
+	dto = s.toPipelineDTO(&v)
+
+	return
+}
+
+// Cancel stops submitting the steps and terminates the running ones.
+func (s *pipelineService) Cancel(pipelineId string) (err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var p domain.Pipeline
+	canceled := false
+
+	err = s.repo.Update(pipelineId, func(v *domain.Pipeline) error {
+		canceled = v.Canceled
+		v.Canceled = true
+		p = *v
+
+		return nil
+	})
+	if err != nil || canceled {
+		return
+	}
+
+	for i := range p.Steps {
+		f, err1 := s.finetunes.Get(p.Steps[i].Finetune.Id)
+		if err1 != nil {
+			continue
+		}
+
+		if st := f.JobDetail.Status; f.Job.JobId == "" || (st != nil && st.IsDone()) {
+			continue
+		}
+
+		if err1 := s.fs.Terminate(f.Job.JobId); err1 != nil {
+			s.log.Errorf(
+				"terminate the job(%s) of pipeline(%s) failed, err:%s",
+				f.Job.JobId, pipelineId, err1.Error(),
+			)
+
+			err = err1
+		}
+	}
+
+	return
+}
+
+func (s *pipelineService) OnFinetuneDone(finetuneId string) {
+	f, err := s.finetunes.Get(finetuneId)
+	if err != nil || f.Pipeline == "" {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err = s.repo.Update(f.Pipeline, func(p *domain.Pipeline) error {
+		s.advance(p)
+
+		return nil
+	})
+	if err != nil {
+		s.log.Errorf("advance pipeline(%s) failed, err:%s", f.Pipeline, err.Error())
+	}
+}
+
+func (s *pipelineService) Resume() {
+	v, err := s.repo.FindAll()
+	if err != nil {
+		s.log.Errorf("find pipelines failed, err:%s", err.Error())
+
+		return
+	}
+
+	for i := range v {
+		if p := &v[i]; !p.Canceled && !p.Settled {
+			s.resume(p.Id)
+		}
+	}
+}
+
+func (s *pipelineService) resume(pipelineId string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := s.repo.Update(pipelineId, func(p *domain.Pipeline) error {
+		s.advance(p)
+
+		return nil
+	})
+	if err != nil {
+		s.log.Errorf("advance pipeline(%s) failed, err:%s", pipelineId, err.Error())
+	}
+}
+
+// advance submits the steps whose dependencies completed successfully
+// and fails the ones whose dependencies failed. It should be called
+// under Update, so the steps are not submitted by others at the same time.
+// The pipeline is settled if all the steps are submitted or failed.
+func (s *pipelineService) advance(p *domain.Pipeline) {
+	submitted := map[string]bool{}
+	statuses := map[string]domain.TrainingStatus{}
+
+	defer func() {
+		for i := range p.Steps {
+			if step := &p.Steps[i]; step.Error == "" && !submitted[step.Name] {
+				return
+			}
+		}
+
+		p.Settled = true
+	}()
+
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Error != "" {
+			continue
+		}
+
+		f, err := s.finetunes.Get(step.Finetune.Id)
+		if err == nil {
+			submitted[step.Name] = true
+			statuses[step.Name] = f.JobDetail.Status
+
+			continue
+		}
+
+		if !repository.IsErrorResourceNotExists(err) {
+			s.log.Errorf(
+				"get finetune(%s) failed, err:%s", step.Finetune.Id, err.Error(),
+			)
+
+			return
+		}
+
+		if p.Canceled {
+			continue
+		}
+
+		ready := true
+		for _, dep := range step.DependsOn {
+			st := statuses[dep]
+
+			if p.Step(dep).Error != "" || (st != nil && st.IsDone() && !st.IsSuccess()) {
+				step.Error = fmt.Sprintf("the step %s it depends on failed", dep)
+				ready = false
+
+				break
+			}
+
+			if !submitted[dep] || st == nil || !st.IsSuccess() {
+				ready = false
+			}
+		}
+
+		if !ready {
+			continue
+		}
+
+		cmd := AICCFinetuneCreateCmd{
+			FinetuneId:   step.Finetune.Id,
+			AICCFinetune: step.Finetune,
+		}
+
+		if _, err := s.fs.Create(&cmd); err != nil {
+			s.log.Errorf(
+				"create finetune(%s) of pipeline failed, err:%s",
+				step.Finetune.Id, err.Error(),
+			)
+
+			// the step is left unsubmitted and will be submitted by Resume,
+			// unless it failed too many times.
+			if watch.IsErrorExceedMaxWatchNum(err) {
+				continue
+			}
+
+			if step.Attempts++; step.Attempts >= maxStepAttempts {
+				step.Error = err.Error()
+			}
+		} else {
+			submitted[step.Name] = true
+		}
+	}
+}
+
+func (s *pipelineService) toPipelineDTO(p *domain.Pipeline) PipelineDTO {
+	dto := PipelineDTO{
+		Id:        p.Id,
+		User:      p.User.Account(),
+		Model:     p.Model.ModelName(),
+		CreatedAt: p.CreatedAt,
+		Steps:     make([]PipelineStepDTO, len(p.Steps)),
+	}
+
+	failed, done := false, true
+
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		item := &dto.Steps[i]
+
+		*item = PipelineStepDTO{
+			Name:       step.Name,
+			Task:       step.Finetune.Task.TaskType(),
+			DependsOn:  step.DependsOn,
+			FinetuneId: step.Finetune.Id,
+			Status:     stepStatusWaiting,
+			Error:      step.Error,
+		}
+
+		if step.Error != "" {
+			item.Status = pipelineStatusFailed
+			failed = true
+
+			continue
+		}
+
+		f, err := s.finetunes.Get(step.Finetune.Id)
+		if err != nil {
+			done = false
+
+			continue
+		}
+
+		st := f.JobDetail.Status
+		if st == nil {
+			done = false
+
+			continue
+		}
+
+		item.Status = st.TrainingStatus()
+
+		if !st.IsDone() {
+			done = false
+		} else if !st.IsSuccess() {
+			failed = true
+		}
+	}
+
+	switch {
+	case p.Canceled:
+		dto.Status = pipelineStatusCanceled
+	case failed:
+		dto.Status = pipelineStatusFailed
+	case done:
+		dto.Status = pipelineStatusCompleted
+	default:
+		dto.Status = pipelineStatusRunning
+	}
+
+	return dto
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+)
+
+func TestPipelineCreateCmdValidate(t *testing.T) {
+	user, _ := domain.NewAccount("alice")
+	model, _ := domain.NewModelName("wukong")
+	name, _ := domain.NewFinetuneName("test")
+
+	step := func(v, task string, deps ...string) domain.PipelineStep {
+		tt, err := domain.NewTaskType(task)
+		if err != nil {
+			t.Fatalf("new task type failed, err:%s", err.Error())
+		}
+
+		s := domain.PipelineStep{Name: v, DependsOn: deps}
+		s.Finetune.Task = tt
+		s.Finetune.Name = name
+
+		return s
+	}
+
+	cases := []struct {
+		name   string
+		steps  []domain.PipelineStep
+		parent string
+	}{
+		{
+			name:   "inference depends on finetune",
+			steps:  []domain.PipelineStep{step("train", "finetune"), step("infer", "inference", "train")},
+			parent: "p-train",
+		},
+		{
+			name:  "finetune depends on finetune",
+			steps: []domain.PipelineStep{step("a", "finetune"), step("b", "finetune", "a")},
+		},
+		{
+			name:  "inference depends on inference",
+			steps: []domain.PipelineStep{step("a", "inference"), step("b", "inference", "a")},
+		},
+		{
+			name: "inference depends on two finetunes",
+			steps: []domain.PipelineStep{
+				step("a", "finetune"), step("b", "finetune"), step("c", "inference", "a", "b"),
+			},
+		},
+	}
+
+	for i := range cases {
+		c := &cases[i]
+
+		t.Run(c.name, func(t *testing.T) {
+			cmd := PipelineCreateCmd{}
+			cmd.Id = "p"
+			cmd.User = user
+			cmd.Model = model
+			cmd.Steps = c.steps
+
+			err := cmd.Validate()
+
+			if c.parent == "" {
+				if err == nil {
+					t.Error("expect error for the dependency which can't be wired")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error:%s", err.Error())
+			}
+
+			if p := cmd.Steps[len(cmd.Steps)-1].Finetune.Parent; p != c.parent {
+				t.Errorf("got parent %s, want %s", p, c.parent)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package app
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+// ReconcileReportDTO records the discrepancies between
+// the jobs on the platform and the known finetunes.
+type ReconcileReportDTO struct {
+	// Rewatched are the finetunes whose jobs were not watched. The job may
+	// be done while nothing watched it, and it will be finalized.
+	Rewatched []string `json:"rewatched"`
+
+	// Adopted are the unknown jobs which are matched
+	// to their finetunes and watched.
+	Adopted []string `json:"adopted"`
+
+	// Terminated are the unknown jobs which are terminated.
+	Terminated []string `json:"terminated"`
+
+	// Orphaned are the unknown jobs which are left running.
+	Orphaned []string `json:"orphaned"`
+
+	// Mismatched are the finetunes which are done,
+	// but their jobs are still running.
+	Mismatched []string `json:"mismatched"`
+
+	// Missing are the finetunes which are not done,
+	// but their jobs are not found.
+	Missing []string `json:"missing"`
+}
+
+func NewJobReconciler(
+	ts aiccfinetune.AICCFinetune,
+	ws watch.WatchService,
+	repo repository.AICCFinetune,
+	policy string,
+	log *logrus.Entry,
+) *JobReconciler {
+	return &JobReconciler{
+		ts:      ts,
+		ws:      ws,
+		log:     log,
+		repo:    repo,
+		policy:  policy,
+		startAt: time.Now().Unix(),
+	}
+}
+
+// JobReconciler matches the jobs on the platform against the known
+// finetunes, so the jobs which are running unwatched can be found.
+// It should run on startup after the watcher restored.
+type JobReconciler struct {
+	ts     aiccfinetune.AICCFinetune
+	ws     watch.WatchService
+	log    *logrus.Entry
+	repo   repository.AICCFinetune
+	policy string
+
+	// startAt is the time when the service starts. The jobs created
+	// after it are ignored, because they are being created right now.
+	startAt int64
+}
+
+func (r *JobReconciler) Reconcile() (report ReconcileReportDTO, err error) {
+	jobs, err := r.ts.ListJobs()
+	if err != nil {
+		return
+	}
+
+	finetunes, err := r.repo.FindAll()
+	if err != nil {
+		return
+	}
+
+	known := make(map[string]*domain.AICCFinetune, len(finetunes))
+	byId := make(map[string]*domain.AICCFinetune, len(finetunes))
+
+	for i := range finetunes {
+		f := &finetunes[i]
+
+		byId[f.Id] = f
+		if f.Job.JobId != "" {
+			known[f.Job.JobId] = f
+		}
+	}
+
+	remote := make(map[string]bool, len(jobs))
+
+	for i := range jobs {
+		job := &jobs[i]
+		remote[job.JobId] = true
+
+		if job.Status == nil || job.CreatedAt >= r.startAt {
+			continue
+		}
+
+		if f, ok := known[job.JobId]; ok {
+			r.reconcileKnown(f, job, &report)
+		} else if !job.Status.IsDone() {
+			r.reconcileUnknown(job, byId[job.FinetuneId], &report)
+		}
+	}
+
+	for i := range finetunes {
+		f := &finetunes[i]
+
+		if f.Job.JobId == "" || remote[f.Job.JobId] || f.Job.CreatedAt >= r.startAt {
+			continue
+		}
+
+		if st := f.JobDetail.Status; st == nil || !st.IsDone() {
+			report.Missing = append(report.Missing, f.Id)
+		}
+	}
+
+	r.log.Infof(
+		"reconcile %d jobs, rewatched:%v, adopted:%v, terminated:%v, "+
+			"orphaned:%v, mismatched:%v, missing:%v",
+		len(jobs), report.Rewatched, report.Adopted, report.Terminated,
+		report.Orphaned, report.Mismatched, report.Missing,
+	)
+
+	return
+}
+
+// reconcileKnown watches the finetune which is not done if it is not
+// watched, so the watcher finalizes it if its job has been done.
+func (r *JobReconciler) reconcileKnown(
+	f *domain.AICCFinetune, job *domain.RemoteJob, report *ReconcileReportDTO,
+) {
+	if r.ws.Watching(f.Id) {
+		return
+	}
+
+	if st := f.JobDetail.Status; st != nil && st.IsDone() {
+		if !job.Status.IsDone() {
+			report.Mismatched = append(report.Mismatched, f.Id)
+		}
+
+		return
+	}
+
+	if err := r.watch(f); err != nil {
+		r.log.Errorf("rewatch finetune(%s) failed, err:%s", f.Id, err.Error())
+
+		return
+	}
+
+	report.Rewatched = append(report.Rewatched, f.Id)
+}
+
+// reconcileUnknown handles the job which no finetune records
+// according to the policy.
+func (r *JobReconciler) reconcileUnknown(
+	job *domain.RemoteJob, f *domain.AICCFinetune, report *ReconcileReportDTO,
+) {
+	switch r.policy {
+	case domain.OrphanPolicyTerminate:
+		if err := r.ts.Terminate(job.JobId); err != nil {
+			r.log.Errorf("terminate job(%s) failed, err:%s", job.JobId, err.Error())
+
+			break
+		}
+
+		report.Terminated = append(report.Terminated, job.JobId)
+
+		return
+
+	case domain.OrphanPolicyAdopt:
+		if f == nil || f.Job.JobId != "" || r.ws.Watching(f.Id) {
+			break
+		}
+
+		if err := r.adopt(f, job); err != nil {
+			r.log.Errorf(
+				"adopt job(%s) for finetune(%s) failed, err:%s",
+				job.JobId, f.Id, err.Error(),
+			)
+
+			break
+		}
+
+		report.Adopted = append(report.Adopted, job.JobId)
+
+		return
+	}
+
+	report.Orphaned = append(report.Orphaned, job.JobId)
+}
+
+func (r *JobReconciler) adopt(f *domain.AICCFinetune, job *domain.RemoteJob) error {
+	f.Job = job.JobInfo
+
+	if err := r.repo.UpdateJob(f.Id, &f.Job); err != nil {
+		return err
+	}
+
+	if f.JobDetail.Status == domain.TrainingStatusScheduled {
+		// the job has been submitted, so it should not be scheduled again.
+		f.JobDetail = domain.JobDetail{Status: job.Status}
+
+		if err := r.repo.UpdateDetail(f.Id, &f.JobDetail); err != nil {
+			return err
+		}
+
+		if err := r.repo.RemoveScheduled(f.Id); err != nil {
+			r.log.Errorf(
+				"remove scheduled finetune(%s) failed, err:%s", f.Id, err.Error(),
+			)
+		}
+	}
+
+	return r.watch(f)
+}
+
+func (r *JobReconciler) watch(f *domain.AICCFinetune) error {
+	a := watch.Admission{
+		FinetuneId:    f.Id,
+		User:          f.User,
+		Task:          f.Task,
+		PriorityClass: f.PriorityClass,
+	}
+
+	return r.ws.ApplyWatch(&a, func(info *watch.FinetuneInfo) error {
+		*info = watch.FinetuneInfo{
+			User:       f.User,
+			Model:      f.Model,
+			Task:       f.Task,
+			FinetuneId: f.Id,
+			JobInfo:    f.Job,
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+func NewFinetuneScheduler(
+	fs FinetuneService,
+	repo repository.AICCFinetune,
+	interval time.Duration,
+	log *logrus.Entry,
+) *FinetuneScheduler {
+	return &FinetuneScheduler{
+		fs:       fs,
+		log:      log,
+		repo:     repo,
+		interval: interval,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// FinetuneScheduler submits the scheduled finetunes when they are due.
+// The scheduled finetunes are persisted, so they survive the restarts.
+// Only the leader replica submits them, see Lead.
+type FinetuneScheduler struct {
+	fs       FinetuneService
+	log      *logrus.Entry
+	repo     repository.AICCFinetune
+	interval time.Duration
+	leading  atomic.Bool
+	tasks    []func()
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func (s *FinetuneScheduler) Run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.submitDue()
+		s.runTasks()
+
+		select {
+		case <-ticker.C:
+
+		case <-s.stop:
+			close(s.stopped)
+
+			return
+		}
+	}
+}
+
+// Lead makes the scheduler start to submit the due finetunes.
+func (s *FinetuneScheduler) Lead() {
+	s.leading.Store(true)
+}
+
+// RegisterTask registers the task which is run by the leader
+// at each interval. It should be called before Run.
+func (s *FinetuneScheduler) RegisterTask(f func()) {
+	s.tasks = append(s.tasks, f)
+}
+
+func (s *FinetuneScheduler) runTasks() {
+	if !s.leading.Load() {
+		return
+	}
+
+	for _, f := range s.tasks {
+		f()
+	}
+}
+
+func (s *FinetuneScheduler) submitDue() {
+	if !s.leading.Load() {
+		return
+	}
+
+	ids, err := s.repo.FindScheduled(time.Now().Unix())
+	if err != nil {
+		s.log.Errorf("find scheduled finetunes failed, err:%s", err.Error())
+
+		return
+	}
+
+	for _, id := range ids {
+		if err := s.fs.SubmitScheduled(id); err != nil {
+			s.log.Errorf(
+				"submit scheduled finetune(%s) failed, err:%s",
+				id, err.Error(),
+			)
+		}
+	}
+}
+
+func (s *FinetuneScheduler) Exit() {
+	close(s.stop)
+
+	<-s.stopped
+}
@@ -0,0 +1,279 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+type SweepCreateCmd struct {
+	domain.Sweep
+}
+
+func (cmd *SweepCreateCmd) Validate() error {
+	b := cmd.Id != "" &&
+		cmd.User != nil &&
+		cmd.Name != nil &&
+		cmd.Model != nil &&
+		cmd.Task != nil &&
+		len(cmd.Space.Params) > 0
+
+	if !b {
+		return errors.New("invalid cmd of creating sweep")
+	}
+
+	if cmd.Task.TaskType() != domain.TaskFinetune {
+		return errors.New("only finetune can be swept")
+	}
+
+	if !cmd.Task.Kind().SupportModel(cmd.Model.ModelName()) {
+		return errors.New("the task does not support the model")
+	}
+
+	return nil
+}
+
+// trialCmd returns the cmd of creating the finetune of index-th trial.
+func (cmd *SweepCreateCmd) trialCmd(index int, kv []domain.KeyValue) AICCFinetuneCreateCmd {
+	id := fmt.Sprintf("%s-%d", cmd.Id, index)
+
+	c := AICCFinetuneCreateCmd{
+		FinetuneId: id,
+		AICCFinetune: domain.AICCFinetune{
+			Id:                 id,
+			User:               cmd.User,
+			Model:              cmd.Model,
+			Task:               cmd.Task,
+			AICCFinetuneConfig: cmd.AICCFinetuneConfig,
+		},
+	}
+
+	c.Hyperparameters = mergeKeyValues(cmd.Hyperparameters, kv)
+
+	return c
+}
+
+type SweepTrialDTO struct {
+	FinetuneId      string            `json:"finetune_id"`
+	Hyperparameters map[string]string `json:"hyperparameters"`
+	Status          string            `json:"status,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	Duration        int               `json:"duration"`
+	Metrics         domain.Metrics    `json:"metrics,omitempty"`
+}
+
+type SweepDTO struct {
+	Id        string `json:"id"`
+	User      string `json:"user"`
+	Model     string `json:"model"`
+	Strategy  string `json:"strategy"`
+	Metric    string `json:"metric"`
+	Maximize  bool   `json:"maximize"`
+	CreatedAt int64  `json:"created_at"`
+
+	// Done means all the trials are done or failed to be created.
+	Done bool `json:"done"`
+
+	// Statuses is the num of trials in each status.
+	Statuses map[string]int `json:"statuses"`
+
+	// Best is the id of finetune which has the best metric.
+	Best       string  `json:"best,omitempty"`
+	BestMetric float64 `json:"best_metric,omitempty"`
+
+	Trials []SweepTrialDTO `json:"trials"`
+}
+
+type SweepService interface {
+	Create(cmd *SweepCreateCmd) (SweepDTO, error)
+	Get(sweepId string) (SweepDTO, error)
+}
+
+func NewSweepService(
+	fs FinetuneService,
+	ws watch.WatchService,
+	repo repository.Sweep,
+	finetunes repository.AICCFinetune,
+	log *logrus.Entry,
+) SweepService {
+	return &sweepService{
+		fs:        fs,
+		ws:        ws,
+		log:       log,
+		repo:      repo,
+		finetunes: finetunes,
+	}
+}
+
+type sweepService struct {
+	fs        FinetuneService
+	ws        watch.WatchService
+	log       *logrus.Entry
+	repo      repository.Sweep
+	finetunes repository.AICCFinetune
+}
+
+func (s *sweepService) Create(cmd *SweepCreateCmd) (dto SweepDTO, err error) {
+	if _, err = s.repo.Get(cmd.Id); err == nil {
+		err = newErrorBadRequest(errors.New("the sweep exists"))
+
+		return
+	}
+
+	if !repository.IsErrorResourceNotExists(err) {
+		return
+	}
+
+	combs := cmd.Space.Combinations()
+
+	if n := s.ws.Available(); n < len(combs) {
+		err = newErrorBadRequest(fmt.Errorf(
+			"the sweep has %d trials, but only %d finetunes can be watched now",
+			len(combs), n,
+		))
+
+		return
+	}
+
+	cmds := make([]AICCFinetuneCreateCmd, len(combs))
+	cmd.Trials = make([]domain.SweepTrial, len(combs))
+
+	for i, kv := range combs {
+		cmds[i] = cmd.trialCmd(i, kv)
+
+		// check the quotas of user before creating any finetune
+		if _, err = s.fs.Render(&cmds[i]); err != nil {
+			err = newErrorBadRequest(fmt.Errorf(
+				"invalid trial %d, err:%s", i, err.Error(),
+			))
+
+			return
+		}
+
+		cmd.Trials[i] = domain.SweepTrial{
+			FinetuneId:      cmds[i].FinetuneId,
+			Hyperparameters: kv,
+		}
+	}
+
+	cmd.CreatedAt = time.Now().Unix()
+
+	if err = s.repo.Save(&cmd.Sweep); err != nil {
+		return
+	}
+
+	failed := false
+	for i := range cmds {
+		if _, err := s.fs.Create(&cmds[i]); err != nil {
+			s.log.Errorf(
+				"create finetune(%s) of sweep failed, err:%s",
+				cmds[i].FinetuneId, err.Error(),
+			)
+
+			cmd.Trials[i].Error = err.Error()
+			failed = true
+		}
+	}
+
+	if failed {
+		if err := s.repo.Save(&cmd.Sweep); err != nil {
+			s.log.Errorf(
+				"save sweep(%s) failed, err:%s", cmd.Id, err.Error(),
+			)
+		}
+	}
+
+	dto = s.toSweepDTO(&cmd.Sweep)
+
+	return
+}
+
+func (s *sweepService) Get(sweepId string) (dto SweepDTO, err error) {
+	v, err := s.repo.Get(sweepId)
+	if err != nil {
+		return
+	}
+
+	dto = s.toSweepDTO(&v)
+
+	return
+}
+
+// toSweepDTO aggregates the statuses and metrics of trials.
+func (s *sweepService) toSweepDTO(t *domain.Sweep) SweepDTO {
+	dto := SweepDTO{
+		Id:        t.Id,
+		User:      t.User.Account(),
+		Model:     t.Model.ModelName(),
+		Strategy:  t.Space.Strategy,
+		Metric:    t.Objective.Metric,
+		Maximize:  t.Objective.Maximize,
+		CreatedAt: t.CreatedAt,
+		Done:      true,
+		Statuses:  map[string]int{},
+		Trials:    make([]SweepTrialDTO, len(t.Trials)),
+	}
+
+	for i := range t.Trials {
+		trial := &t.Trials[i]
+		item := &dto.Trials[i]
+
+		item.FinetuneId = trial.FinetuneId
+		item.Error = trial.Error
+		item.Hyperparameters = make(map[string]string, len(trial.Hyperparameters))
+
+		for _, kv := range trial.Hyperparameters {
+			item.Hyperparameters[kv.Key.CustomizedKey()] = kv.Value.CustomizedValue()
+		}
+
+		if trial.Error != "" {
+			continue
+		}
+
+		v, err := s.finetunes.Get(trial.FinetuneId)
+		if err != nil {
+			s.log.Errorf(
+				"get finetune(%s) of sweep failed, err:%s",
+				trial.FinetuneId, err.Error(),
+			)
+
+			dto.Done = false
+
+			continue
+		}
+
+		detail := &v.JobDetail
+
+		item.Duration = detail.Duration
+		item.Metrics = detail.Metrics
+
+		if detail.Status == nil || !detail.Status.IsDone() {
+			dto.Done = false
+		}
+
+		if detail.Status == nil {
+			continue
+		}
+
+		item.Status = detail.Status.TrainingStatus()
+		dto.Statuses[item.Status]++
+
+		if !detail.Status.IsSuccess() {
+			continue
+		}
+
+		m, ok := detail.Metrics[t.Objective.Metric]
+		if ok && (dto.Best == "" || t.Objective.Better(m, dto.BestMetric)) {
+			dto.Best = trial.FinetuneId
+			dto.BestMetric = m
+		}
+	}
+
+	return dto
+}
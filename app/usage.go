@@ -0,0 +1,231 @@
+package app
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+// UsageListCmd filters the usages. The empty field matches all.
+type UsageListCmd struct {
+	User  string
+	Model string
+	Month string
+}
+
+func (cmd *UsageListCmd) match(u *domain.Usage) bool {
+	return (cmd.User == "" || cmd.User == u.User) &&
+		(cmd.Model == "" || cmd.Model == u.Model) &&
+		(cmd.Month == "" || cmd.Month == u.Month())
+}
+
+// UsageDTO is the usage aggregated by user, model and month.
+type UsageDTO struct {
+	User      string  `json:"user"`
+	Model     string  `json:"model"`
+	Month     string  `json:"month"`
+	Jobs      int     `json:"jobs"`
+	Duration  int     `json:"duration"`
+	CardHours float64 `json:"card_hours"`
+}
+
+type UsageReportDTO struct {
+	Items     []UsageDTO `json:"items"`
+	Jobs      int        `json:"jobs"`
+	CardHours float64    `json:"card_hours"`
+}
+
+// UsageService records the resource consumed by the jobs
+// and aggregates it for billing or fair use.
+type UsageService interface {
+	List(cmd *UsageListCmd) (UsageReportDTO, error)
+
+	// OnFinetuneDone records the usages of the job of finetune
+	// and the jobs which were retried.
+	OnFinetuneDone(finetuneId string)
+
+	// Backfill records the usages of the done finetunes which were
+	// missed, such as the service exited before OnFinetuneDone.
+	Backfill()
+}
+
+func NewUsageService(
+	repo repository.Usage,
+	finetunes repository.AICCFinetune,
+	log *logrus.Entry,
+) UsageService {
+	return usageService{
+		log:       log,
+		repo:      repo,
+		finetunes: finetunes,
+	}
+}
+
+type usageService struct {
+	log       *logrus.Entry
+	repo      repository.Usage
+	finetunes repository.AICCFinetune
+}
+
+func (s usageService) OnFinetuneDone(finetuneId string) {
+	f, err := s.finetunes.Get(finetuneId)
+	if err != nil {
+		s.log.Errorf("get finetune(%s) failed, err:%s", finetuneId, err.Error())
+
+		return
+	}
+
+	if f.Job.JobId == "" {
+		return
+	}
+
+	s.save(&f, nil)
+}
+
+func (s usageService) Backfill() {
+	v, err := s.repo.FindAll()
+	if err != nil {
+		s.log.Errorf("find usages failed, err:%s", err.Error())
+
+		return
+	}
+
+	recorded := make(map[string]bool, len(v))
+	for i := range v {
+		recorded[v[i].JobId] = true
+	}
+
+	finetunes, err := s.finetunes.FindAll()
+	if err != nil {
+		s.log.Errorf("find finetunes failed, err:%s", err.Error())
+
+		return
+	}
+
+	for i := range finetunes {
+		f := &finetunes[i]
+
+		if st := f.JobDetail.Status; f.Job.JobId != "" && st != nil && st.IsDone() {
+			s.save(f, recorded)
+		}
+	}
+}
+
+// save saves the usages of the jobs of finetune except the recorded ones.
+func (s usageService) save(f *domain.AICCFinetune, recorded map[string]bool) {
+	for _, u := range toUsages(f) {
+		if recorded[u.JobId] {
+			continue
+		}
+
+		if err := s.repo.Save(&u); err != nil {
+			s.log.Errorf("save usage of job(%s) failed, err:%s", u.JobId, err.Error())
+		}
+	}
+}
+
+// toUsages returns the usages of the jobs of finetune. The retried
+// jobs were created with the same resource as the current one.
+func toUsages(f *domain.AICCFinetune) []domain.Usage {
+	base := domain.Usage{
+		FinetuneId: f.Id,
+		User:       f.User.Account(),
+		Model:      f.Model.ModelName(),
+		Task:       f.Task.TaskType(),
+		Flavor:     f.Job.Flavor,
+		NodeCount:  f.Job.NodeCount,
+		CardNum:    f.Job.CardNum,
+	}
+
+	if base.Flavor == "" {
+		base.Flavor = f.Resource.Flavor
+	}
+
+	if base.NodeCount <= 0 {
+		base.NodeCount = f.Resource.NodeCount
+	}
+
+	r := make([]domain.Usage, 0, len(f.JobDetail.Attempts)+1)
+
+	for i := range f.JobDetail.Attempts {
+		a := &f.JobDetail.Attempts[i]
+
+		u := base
+		u.JobId = a.JobId
+		u.Status = a.Status.TrainingStatus()
+		u.Duration = a.Duration
+		u.FinishedAt = domain.FinishedAt(a.CreatedAt, a.StartedAt, a.Duration)
+
+		r = append(r, u)
+	}
+
+	u := base
+	u.JobId = f.Job.JobId
+	u.Duration = f.JobDetail.Duration
+	u.FinishedAt = domain.FinishedAt(f.Job.CreatedAt, f.JobDetail.StartedAt, u.Duration)
+
+	if f.JobDetail.Status != nil {
+		u.Status = f.JobDetail.Status.TrainingStatus()
+	}
+
+	return append(r, u)
+}
+
+func (s usageService) List(cmd *UsageListCmd) (dto UsageReportDTO, err error) {
+	v, err := s.repo.FindAll()
+	if err != nil {
+		return
+	}
+
+	type key struct {
+		user, model, month string
+	}
+
+	items := map[key]*UsageDTO{}
+
+	for i := range v {
+		u := &v[i]
+		if !cmd.match(u) {
+			continue
+		}
+
+		k := key{u.User, u.Model, u.Month()}
+
+		item, ok := items[k]
+		if !ok {
+			item = &UsageDTO{User: k.user, Model: k.model, Month: k.month}
+			items[k] = item
+		}
+
+		item.Jobs++
+		item.Duration += u.Duration
+		item.CardHours += u.CardHours()
+	}
+
+	dto.Items = make([]UsageDTO, 0, len(items))
+	for _, item := range items {
+		dto.Jobs += item.Jobs
+		dto.CardHours += item.CardHours
+
+		dto.Items = append(dto.Items, *item)
+	}
+
+	sort.Slice(dto.Items, func(i, j int) bool {
+		a, b := &dto.Items[i], &dto.Items[j]
+
+		if a.Month != b.Month {
+			return a.Month < b.Month
+		}
+
+		if a.User != b.User {
+			return a.User < b.User
+		}
+
+		return a.Model < b.Model
+	})
+
+	return
+}
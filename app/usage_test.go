@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+)
+
+func TestToUsagesFinishedAt(t *testing.T) {
+	user, _ := domain.NewAccount("alice")
+	model, _ := domain.NewModelName("wukong")
+	task, _ := domain.NewTaskType(domain.TaskFinetune)
+
+	// 2023-05-31 23:00 UTC
+	createdAt := int64(1685574000)
+
+	f := domain.AICCFinetune{
+		Id:    "1",
+		User:  user,
+		Model: model,
+		Task:  task,
+		Job:   domain.JobInfo{JobId: "job-2", CreatedAt: createdAt},
+		JobDetail: domain.JobDetail{
+			Status: domain.TrainingStatusCompleted,
+			// it queued 2 hours and ran 1 hour.
+			StartedAt: createdAt + 7200,
+			Duration:  3600,
+			Attempts: []domain.JobAttempt{
+				// recorded before the start time is known.
+				{JobId: "job-1", Status: domain.TrainingStatusFailed, Duration: 60, CreatedAt: createdAt - 600},
+			},
+		},
+	}
+
+	v := toUsages(&f)
+	if len(v) != 2 {
+		t.Fatalf("got %d usages, want 2", len(v))
+	}
+
+	if u := &v[0]; u.FinishedAt != createdAt-540 || u.Month() != "2023-05" {
+		t.Errorf("got the attempt finished at %d in %s", u.FinishedAt, u.Month())
+	}
+
+	if u := &v[1]; u.FinishedAt != createdAt+10800 || u.Month() != "2023-06" {
+		t.Errorf("got the job finished at %d in %s, want the queue time counted", u.FinishedAt, u.Month())
+	}
+}
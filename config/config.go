@@ -1,7 +1,14 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/repositoryimpl"
+	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/statussinkimpl"
 	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/watchimpl"
 )
 
@@ -19,6 +26,14 @@ type Config struct {
 	AICC     AICCConfig       `json:"aicc"         required:"true"`
 	Upload   UploadConfig     `json:"upload"         required:"true"`
 	OBS      OBSConfig        `json:"obs"         required:"true"`
+
+	Repository repositoryimpl.Config `json:"repository" required:"true"`
+	Scheduler  SchedulerConfig       `json:"scheduler"`
+	Reconciler ReconcilerConfig      `json:"reconciler"`
+
+	LeaderElection LeaderElectionConfig `json:"leader_election"`
+
+	StatusSink statussinkimpl.Config `json:"status_sink"`
 }
 
 func (cfg *Config) configItems() []interface{} {
@@ -26,6 +41,11 @@ func (cfg *Config) configItems() []interface{} {
 		&cfg.Watch,
 		&cfg.Finetune,
 		&cfg.AICC,
+		&cfg.Upload,
+		&cfg.Scheduler,
+		&cfg.Reconciler,
+		&cfg.LeaderElection,
+		&cfg.StatusSink,
 	}
 }
 
@@ -48,6 +68,11 @@ func (cfg *Config) validate() error {
 }
 
 func (cfg *Config) setDefault() {
+	// the endpoint of watch was the address of grpc server.
+	if cfg.StatusSink.Type == "" && cfg.StatusSink.Endpoint == "" {
+		cfg.StatusSink.Endpoint = cfg.Watch.Endpoint
+	}
+
 	items := cfg.configItems()
 
 	for _, i := range items {
@@ -111,6 +136,64 @@ type AICCConfig struct {
 
 type FinetuneConfig struct {
 	WukongConfig ModelConfig `json:"wukong"`
+
+	// Entitlements specifies the resources which the users
+	// can use. The users not listed can only use the default
+	// flavor with one node.
+	Entitlements []EntitlementConfig `json:"entitlements"`
+}
+
+func (cfg *FinetuneConfig) SetDefault() {
+	cfg.WukongConfig.SetDefault()
+}
+
+func (cfg *FinetuneConfig) Validate() error {
+	return cfg.WukongConfig.Validate()
+}
+
+// Entitlement returns the entitlement of user.
+func (cfg *FinetuneConfig) Entitlement(user string) *EntitlementConfig {
+	for i := range cfg.Entitlements {
+		if cfg.Entitlements[i].hasUser(user) {
+			return &cfg.Entitlements[i]
+		}
+	}
+
+	return nil
+}
+
+type EntitlementConfig struct {
+	Users []string `json:"users"`
+
+	// Flavors specifies the names of flavor which the users can use.
+	// All the flavors of model can be used if it is empty.
+	Flavors []string `json:"flavors"`
+
+	MaxNodeCount int `json:"max_node_count"`
+}
+
+func (cfg *EntitlementConfig) hasUser(user string) bool {
+	for _, v := range cfg.Users {
+		if v == user {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cfg *EntitlementConfig) HasFlavor(name string) bool {
+	if len(cfg.Flavors) == 0 {
+		return true
+	}
+
+	for _, v := range cfg.Flavors {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
 }
 
 type ModelConfig struct {
@@ -125,6 +208,152 @@ type ModelConfig struct {
 	LogDir     string `json:"log_dir"`
 	ModelDir   string `json:"ckpt_file"`
 	ImageURL   string `json:"image_url"`
+
+	// Flavors specifies the flavors which can be chosen. The first
+	// one is the default. It will be the flavor of FlavorId if empty.
+	Flavors []FlavorConfig `json:"flavors"`
+
+	// MaxNodeCount specifies the max num of nodes of a job.
+	MaxNodeCount int `json:"max_node_count"`
+
+	// MaxQueueTime specifies the max time that a job can wait
+	// before running. The unit is second. It is 10 days by default
+	// which is the timeout of watching that limited the pending job.
+	MaxQueueTime int `json:"max_queue_time"`
+
+	// MaxRunTime specifies the max time that a job can run.
+	// The unit is second.
+	MaxRunTime int `json:"max_run_time"`
+
+	// Pools specifies the candidate pools of job.
+	// It will be the pool of PoolId if empty.
+	Pools []PoolConfig `json:"pools"`
+
+	// Placement specifies the strategy of choosing pool which is
+	// one of least-pending, round-robin and failover. Failover
+	// chooses the pools in order and it is the default one.
+	Placement string `json:"placement"`
+}
+
+func (cfg *ModelConfig) SetDefault() {
+	if len(cfg.Flavors) == 0 && cfg.FlavorId != "" {
+		cfg.Flavors = []FlavorConfig{{
+			Name:    "default",
+			Id:      cfg.FlavorId,
+			CardNum: 1,
+		}}
+	}
+
+	for i := range cfg.Flavors {
+		if cfg.Flavors[i].CardNum <= 0 {
+			cfg.Flavors[i].CardNum = 1
+		}
+	}
+
+	if cfg.MaxNodeCount <= 0 {
+		cfg.MaxNodeCount = 1
+	}
+
+	if cfg.MaxQueueTime <= 0 {
+		cfg.MaxQueueTime = 864000
+	}
+
+	if cfg.MaxRunTime <= 0 {
+		cfg.MaxRunTime = 864000
+	}
+
+	if len(cfg.Pools) == 0 && cfg.PoolId != "" {
+		cfg.Pools = []PoolConfig{{
+			Id:   cfg.PoolId,
+			Name: cfg.PoolName,
+		}}
+	}
+
+	for i := range cfg.Pools {
+		if cfg.Pools[i].Weight <= 0 {
+			cfg.Pools[i].Weight = 1
+		}
+	}
+
+	if cfg.Placement == "" {
+		cfg.Placement = PlacementFailover
+	}
+}
+
+func (cfg *ModelConfig) Validate() error {
+	names := make(map[string]bool, len(cfg.Flavors))
+
+	for i := range cfg.Flavors {
+		item := &cfg.Flavors[i]
+
+		if item.Name == "" || item.Id == "" {
+			return errors.New("missing name or id of flavor")
+		}
+
+		if names[item.Name] {
+			return fmt.Errorf("duplicate flavor: %s", item.Name)
+		}
+
+		names[item.Name] = true
+	}
+
+	for i := range cfg.Pools {
+		if cfg.Pools[i].Id == "" {
+			return errors.New("missing id of pool")
+		}
+	}
+
+	switch cfg.Placement {
+	case PlacementLeastPending, PlacementRoundRobin, PlacementFailover:
+	default:
+		return fmt.Errorf("unknown placement: %s", cfg.Placement)
+	}
+
+	return nil
+}
+
+// Flavor returns the flavor of name, or the default one if name is empty.
+func (cfg *ModelConfig) Flavor(name string) (*FlavorConfig, error) {
+	if len(cfg.Flavors) == 0 {
+		return nil, errors.New("no flavor is configured")
+	}
+
+	if name == "" {
+		return &cfg.Flavors[0], nil
+	}
+
+	for i := range cfg.Flavors {
+		if cfg.Flavors[i].Name == name {
+			return &cfg.Flavors[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported flavor: %s", name)
+}
+
+const (
+	PlacementLeastPending = "least-pending"
+	PlacementRoundRobin   = "round-robin"
+	PlacementFailover     = "failover"
+)
+
+type PoolConfig struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+
+	// Weight specifies the share of jobs the pool should take.
+	Weight int `json:"weight"`
+}
+
+type FlavorConfig struct {
+	// Name is the name chosen by user, such as 1x-npu.
+	Name string `json:"name"`
+
+	// Id is the flavor id of aicc.
+	Id string `json:"id"`
+
+	// CardNum specifies the num of cards of each node.
+	CardNum int `json:"card_num"`
 }
 
 type UploadConfig struct {
@@ -135,12 +364,101 @@ type UploadConfig struct {
 	// The unit is second.
 	DownloadExpiry int    `json:"download_expiry"`
 	OBSUtilPath    string `json:"obsutil_path"             required:"true"`
+
+	// ThumbnailSize specifies the max width and height
+	// of the thumbnail of generated image.
+	ThumbnailSize int `json:"thumbnail_size"`
 }
 
 func (c *UploadConfig) SetDefault() {
 	if c.DownloadExpiry <= 0 {
 		c.DownloadExpiry = 3600
 	}
+
+	if c.ThumbnailSize <= 0 {
+		c.ThumbnailSize = 256
+	}
+}
+
+type SchedulerConfig struct {
+	// Interval specifies the interval of second to check
+	// whether the scheduled finetunes are due.
+	Interval int `json:"interval"`
+}
+
+func (c *SchedulerConfig) SetDefault() {
+	if c.Interval <= 0 {
+		c.Interval = 60
+	}
+}
+
+type ReconcilerConfig struct {
+	// Disable means the jobs will not be reconciled on startup.
+	Disable bool `json:"disable"`
+
+	// Policy specifies how to handle the unknown jobs which are
+	// running on the platform. It can be report, adopt or terminate.
+	Policy string `json:"policy"`
+}
+
+func (c *ReconcilerConfig) SetDefault() {
+	if c.Policy == "" {
+		c.Policy = domain.OrphanPolicyReport
+	}
+}
+
+func (c *ReconcilerConfig) Validate() error {
+	if !domain.IsOrphanPolicy(c.Policy) {
+		return fmt.Errorf("unsupported policy of reconciler: %s", c.Policy)
+	}
+
+	return nil
+}
+
+// LeaderElectionConfig is used when running several replicas. Only the
+// leader watches the finetunes, and all the replicas serve the api.
+// The repository dir should be shared by the replicas.
+type LeaderElectionConfig struct {
+	Enable bool `json:"enable"`
+
+	// Identity is the name of replica. It is the hostname by default.
+	Identity string `json:"identity"`
+
+	// LeaseDuration specifies the time that the leader holds the lease
+	// after renewing it. RenewInterval specifies the interval to renew
+	// the lease. The unit is second.
+	LeaseDuration int `json:"lease_duration"`
+	RenewInterval int `json:"renew_interval"`
+}
+
+func (c *LeaderElectionConfig) SetDefault() {
+	if c.Identity == "" {
+		c.Identity, _ = os.Hostname()
+	}
+
+	if c.LeaseDuration <= 0 {
+		c.LeaseDuration = 15
+	}
+
+	if c.RenewInterval <= 0 {
+		c.RenewInterval = 5
+	}
+}
+
+func (c *LeaderElectionConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+
+	if c.Identity == "" {
+		return errors.New("missing identity of leader election")
+	}
+
+	if c.RenewInterval >= c.LeaseDuration {
+		return errors.New("the renew interval should be less than the lease duration")
+	}
+
+	return nil
 }
 
 type OBSConfig struct {
@@ -14,9 +14,14 @@ func AddRouterForAICCFinetuneController(
 	ctl := AICCFinetuneController{fs: fs}
 
 	rg.POST("/v1/aiccfinetune", ctl.Create)
+	rg.POST("/v1/aiccfinetune/render", ctl.Render)
+	rg.GET("/v1/aiccfinetune/:id", ctl.Get)
 	rg.DELETE("/v1/aiccfinetune/:id", ctl.Delete)
 	rg.PUT("/v1/aiccfinetune/:id", ctl.Terminate)
+	rg.POST("/v1/aiccfinetune/:id/resume", ctl.Resume)
+	rg.POST("/v1/aiccfinetune/:id/clone", ctl.Clone)
 	rg.GET("/v1/aiccfinetune/:id/log", ctl.GetLog)
+	rg.GET("/v1/aiccfinetune/:id/images", ctl.GetImages)
 	rg.GET("/v1/aiccfinetune/:id/result/:file", ctl.GetDownloadURL)
 
 }
@@ -55,7 +60,122 @@ func (ctl *AICCFinetuneController) Create(ctx *gin.Context) {
 
 	v, err := ctl.fs.Create(cmd)
 	if err != nil {
-		ctl.sendRespWithInternalError(ctx, newResponseError(err))
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newResponseData(v))
+}
+
+//	@Summary		Render
+//	@Description	render the aicc job payload of creating aicc finetune without submitting it
+//	@Tags			AICC Finetune
+//	@Param			body	body	AICCFinetuneCreateRequest	true	"body of creating aicc finetune"
+//	@Accept			json
+//	@Success		200	{object}			app.JobPayloadDTO
+//	@Failure		400	bad_request_body	can't	parse		request	body
+//	@Failure		401	bad_request_param	some	parameter	of		body	is	invalid
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/aiccfinetune/render [post]
+func (ctl *AICCFinetuneController) Render(ctx *gin.Context) {
+	req := AICCFinetuneCreateRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, respBadRequestBody)
+
+		return
+	}
+
+	cmd := new(app.AICCFinetuneCreateCmd)
+	if err := req.toCmd(cmd); err != nil {
+		ctx.JSON(http.StatusBadRequest, newResponseCodeError(
+			errorBadRequestParam, err,
+		))
+
+		return
+	}
+
+	v, err := ctl.fs.Render(cmd)
+	if err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newResponseData(v))
+}
+
+//	@Summary		Resume
+//	@Description	create a finetune which resumes from the latest checkpoint of a terminated or failed finetune
+//	@Tags			AICC Finetune
+//	@Param			id		path	string						true	"id of finetune to be resumed"
+//	@Param			body	body	AICCFinetuneResumeRequest	true	"body of resuming aicc finetune"
+//	@Accept			json
+//	@Success		201	{object}			app.JobInfoDTO
+//	@Failure		400	bad_request_body	can't	parse		request	body
+//	@Failure		401	bad_request_param	some	parameter	of		body	is	invalid
+//	@Failure		404	resource_not_exists	finetune	not	exists
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/aiccfinetune/{id}/resume [post]
+func (ctl *AICCFinetuneController) Resume(ctx *gin.Context) {
+	req := AICCFinetuneResumeRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, respBadRequestBody)
+
+		return
+	}
+
+	cmd := req.toCmd(ctx.Param("id"))
+	if err := cmd.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, newResponseCodeError(
+			errorBadRequestParam, err,
+		))
+
+		return
+	}
+
+	v, err := ctl.fs.Resume(&cmd)
+	if err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newResponseData(v))
+}
+
+//	@Summary		Clone
+//	@Description	create a finetune with the config of an existing one and the overrides
+//	@Tags			AICC Finetune
+//	@Param			id		path	string						true	"id of finetune to be cloned"
+//	@Param			body	body	AICCFinetuneCloneRequest	true	"body of cloning aicc finetune"
+//	@Accept			json
+//	@Success		201	{object}			app.JobInfoDTO
+//	@Failure		400	bad_request_body	can't	parse		request	body
+//	@Failure		401	bad_request_param	some	parameter	of		body	is	invalid
+//	@Failure		404	resource_not_exists	finetune	not	exists
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/aiccfinetune/{id}/clone [post]
+func (ctl *AICCFinetuneController) Clone(ctx *gin.Context) {
+	req := AICCFinetuneCloneRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, respBadRequestBody)
+
+		return
+	}
+
+	cmd := app.AICCFinetuneCloneCmd{}
+	if err := req.toCmd(ctx.Param("id"), &cmd); err != nil {
+		ctx.JSON(http.StatusBadRequest, newResponseCodeError(
+			errorBadRequestParam, err,
+		))
+
+		return
+	}
+
+	v, err := ctl.fs.Clone(&cmd)
+	if err != nil {
+		ctl.sendRespWithError(ctx, err)
 
 		return
 	}
@@ -105,6 +225,26 @@ func (ctl *AICCFinetuneController) Terminate(ctx *gin.Context) {
 	ctx.JSON(http.StatusAccepted, newResponseData("success"))
 }
 
+//	@Summary		Get
+//	@Description	get the detail of aicc finetune
+//	@Tags			AICC Finetune
+//	@Param			id	path	string	true	"id of finetune"
+//	@Accept			json
+//	@Success		200	{object}			app.AICCFinetuneDTO
+//	@Failure		404	resource_not_exists	finetune	not	exists
+//	@Failure		500	system_error		system		error
+//	@Router			/v1/aiccfinetune/{id} [get]
+func (ctl *AICCFinetuneController) Get(ctx *gin.Context) {
+	v, err := ctl.fs.Get(ctx.Param("id"))
+	if err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newResponseData(v))
+}
+
 //	@Summary		GetLog
 //	@Description	get log url of aicc finetune for downloading
 //	@Tags			AICC Finetune
@@ -124,6 +264,26 @@ func (ctl *AICCFinetuneController) GetLog(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, newResponseData(AICCFinetuneResultResp{v}))
 }
 
+//	@Summary		GetImages
+//	@Description	get the download urls of images generated by inference and their thumbnails.
+//	@Tags			AICC Finetune
+//	@Param			id	path	string	true	"id of finetune"
+//	@Accept			json
+//	@Success		200	{object}			[]app.InferenceImageDTO
+//	@Failure		404	resource_not_exists	finetune	not	exists
+//	@Failure		500	system_error		system		error
+//	@Router			/v1/aiccfinetune/{id}/images [get]
+func (ctl *AICCFinetuneController) GetImages(ctx *gin.Context) {
+	v, err := ctl.fs.GetImages(ctx.Param("id"))
+	if err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newResponseData(v))
+}
+
 //	@Summary		GetDownloadURL
 //	@Description	get download url of aicc finetune result such as log or output.
 //	@Tags			AICC Finetune
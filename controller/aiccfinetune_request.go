@@ -2,6 +2,7 @@ package controller
 
 import (
 	"errors"
+	"time"
 
 	"github.com/opensourceways/xihe-aicc-finetune/app"
 	"github.com/opensourceways/xihe-aicc-finetune/domain"
@@ -17,14 +18,61 @@ type AICCFinetuneCreateRequest struct {
 	FinetuneId string `json:"finetune_id"`
 	Task       string `json:"task"`
 
+	// Parent is the id of finetune whose output will be
+	// used as the checkpoint, such as for evaluation or inference.
+	Parent string `json:"parent"`
+
 	Name string `json:"name"`
 	Desc string `json:"desc"`
 
+	// EvalSet is the directory of evaluation set
+	// under the data directory of user.
+	EvalSet string `json:"eval_set"`
+
+	// Inference is the structured prompts of text-to-image inference.
+	Inference *AICCInferenceOption `json:"inference"`
+
+	// Flavor is the name of flavor which is one of the flavors
+	// of model. The default flavor will be used if empty.
+	Flavor    string `json:"flavor"`
+	NodeCount int    `json:"node_count"`
+
+	// MaxQueueTime and MaxRunTime override the time limits of model
+	// and should not exceed them. The unit is second.
+	MaxQueueTime int `json:"max_queue_time"`
+	MaxRunTime   int `json:"max_run_time"`
+
+	// Retry is the policy of resubmitting the job if it failed.
+	Retry *AICCRetryPolicy `json:"retry"`
+
+	// StartAfter is the unix time after which the job will be submitted.
+	// Schedule is the cron expression of 5 fields, such as "0 2 * * *",
+	// and the job will be submitted at the first time matching it.
+	StartAfter int64  `json:"start_after"`
+	Schedule   string `json:"schedule"`
+
+	// PriorityClass is the class for admission, such as interactive.
+	// The class of user or task will be used if it is empty.
+	PriorityClass string `json:"priority_class"`
+
 	Hyperparameters []AICCKeyValue `json:"hyperparameter"`
 	Env             []AICCKeyValue `json:"env"`
 }
 
 func (req *AICCFinetuneCreateRequest) toCmd(cmd *app.AICCFinetuneCreateCmd) (err error) {
+	if err = req.toFinetune(&cmd.AICCFinetune); err != nil {
+		return
+	}
+
+	cmd.FinetuneId = req.FinetuneId
+	cmd.Parent = req.Parent
+
+	err = cmd.Validate()
+
+	return
+}
+
+func (req *AICCFinetuneCreateRequest) toFinetune(cmd *domain.AICCFinetune) (err error) {
 	if cmd.User, err = domain.NewAccount(req.User); err != nil {
 		return
 	}
@@ -41,20 +89,52 @@ func (req *AICCFinetuneCreateRequest) toCmd(cmd *app.AICCFinetuneCreateCmd) (err
 		return
 	}
 
-	if cmd.Env, err = req.toKeyValue(req.Env); err != nil {
+	if cmd.Env, err = toKeyValues(req.Env); err != nil {
+		return
+	}
+
+	if cmd.Hyperparameters, err = toKeyValues(req.Hyperparameters); err != nil {
+		return
+	}
+
+	if cmd.Task, err = domain.NewTaskType(req.Task); err != nil {
+		return
+	}
+
+	if cmd.EvalSet, err = domain.NewDirectory(req.EvalSet); err != nil {
+		return
+	}
+
+	if cmd.Resource, err = domain.NewJobResource(req.Flavor, req.NodeCount); err != nil {
+		return
+	}
+
+	if cmd.Limits, err = domain.NewJobLimits(req.MaxQueueTime, req.MaxRunTime); err != nil {
 		return
 	}
 
-	if cmd.Hyperparameters, err = req.toKeyValue(req.Hyperparameters); err != nil {
+	if req.Retry != nil {
+		if cmd.Retry, err = domain.NewRetryPolicy(req.Retry.MaxRetries, req.Retry.Statuses); err != nil {
+			return
+		}
+	}
+
+	if req.Inference != nil {
+		if cmd.Inference, err = req.Inference.toOption(); err != nil {
+			return
+		}
+	}
+
+	if cmd.Schedule, err = domain.NewJobSchedule(req.StartAfter, req.Schedule, time.Now()); err != nil {
 		return
 	}
 
-	cmd.Task = req.Task
+	cmd.PriorityClass = req.PriorityClass
 
 	return
 }
 
-func (req *AICCFinetuneCreateRequest) toKeyValue(kv []AICCKeyValue) (r []domain.KeyValue, err error) {
+func toKeyValues(kv []AICCKeyValue) (r []domain.KeyValue, err error) {
 	n := len(kv)
 	if n == 0 {
 		return nil, nil
@@ -70,6 +150,116 @@ func (req *AICCFinetuneCreateRequest) toKeyValue(kv []AICCKeyValue) (r []domain.
 	return
 }
 
+type AICCFinetuneResumeRequest struct {
+	// FinetuneId is the id of the new finetune.
+	FinetuneId string `json:"finetune_id"`
+}
+
+func (req *AICCFinetuneResumeRequest) toCmd(original string) app.AICCFinetuneResumeCmd {
+	return app.AICCFinetuneResumeCmd{
+		FinetuneId: req.FinetuneId,
+		Original:   original,
+	}
+}
+
+// AICCFinetuneCloneRequest clones a finetune. The fields which
+// are empty mean to keep the ones of original finetune.
+type AICCFinetuneCloneRequest struct {
+	// FinetuneId is the id of the new finetune.
+	FinetuneId string `json:"finetune_id"`
+
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+
+	Inference *AICCInferenceOption `json:"inference"`
+
+	Flavor    string `json:"flavor"`
+	NodeCount int    `json:"node_count"`
+
+	MaxQueueTime int `json:"max_queue_time"`
+	MaxRunTime   int `json:"max_run_time"`
+
+	// Hyperparameters and Env override the original
+	// ones which have the same key.
+	Hyperparameters []AICCKeyValue `json:"hyperparameter"`
+	Env             []AICCKeyValue `json:"env"`
+}
+
+func (req *AICCFinetuneCloneRequest) toCmd(original string, cmd *app.AICCFinetuneCloneCmd) (err error) {
+	if req.Name != "" {
+		if cmd.Name, err = domain.NewFinetuneName(req.Name); err != nil {
+			return
+		}
+	}
+
+	if cmd.Desc, err = domain.NewFinetuneDesc(req.Desc); err != nil {
+		return
+	}
+
+	if cmd.Env, err = toKeyValues(req.Env); err != nil {
+		return
+	}
+
+	if cmd.Hyperparameters, err = toKeyValues(req.Hyperparameters); err != nil {
+		return
+	}
+
+	if req.Inference != nil {
+		if cmd.Inference, err = req.Inference.toOption(); err != nil {
+			return
+		}
+	}
+
+	if req.Flavor != "" || req.NodeCount != 0 {
+		v, err := domain.NewJobResource(req.Flavor, req.NodeCount)
+		if err != nil {
+			return err
+		}
+
+		cmd.Resource = &v
+	}
+
+	if req.MaxQueueTime != 0 || req.MaxRunTime != 0 {
+		v, err := domain.NewJobLimits(req.MaxQueueTime, req.MaxRunTime)
+		if err != nil {
+			return err
+		}
+
+		cmd.Limits = &v
+	}
+
+	cmd.FinetuneId = req.FinetuneId
+	cmd.Original = original
+
+	err = cmd.Validate()
+
+	return
+}
+
+type AICCRetryPolicy struct {
+	MaxRetries int `json:"max_retries"`
+
+	// Statuses are the statuses of job which will be retried,
+	// such as Failed and Abnormal. It is Abnormal if empty.
+	Statuses []string `json:"statuses"`
+}
+
+type AICCInferenceOption struct {
+	Prompts         []string `json:"prompts"`
+	NegativePrompts []string `json:"negative_prompts"`
+	Seed            int      `json:"seed"`
+	SampleCount     int      `json:"sample_count"`
+	Width           int      `json:"width"`
+	Height          int      `json:"height"`
+}
+
+func (opt *AICCInferenceOption) toOption() (*domain.InferenceOption, error) {
+	return domain.NewInferenceOption(
+		opt.Prompts, opt.NegativePrompts,
+		opt.Seed, opt.SampleCount, opt.Width, opt.Height,
+	)
+}
+
 type AICCKeyValue struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -5,6 +5,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/xihe-aicc-finetune/app"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
 )
 
 var (
@@ -17,6 +20,26 @@ func Init(l *logrus.Entry) {
 
 type baseController struct{}
 
+func (ctl baseController) sendRespWithError(ctx *gin.Context, err error) {
+	if repository.IsErrorResourceNotExists(err) {
+		ctx.JSON(http.StatusNotFound, newResponseCodeError(
+			errorResourceNotExists, err,
+		))
+
+		return
+	}
+
+	if app.IsErrorBadRequest(err) {
+		ctx.JSON(http.StatusBadRequest, newResponseCodeError(
+			errorBadRequestParam, err,
+		))
+
+		return
+	}
+
+	ctl.sendRespWithInternalError(ctx, newResponseError(err))
+}
+
 func (ctl baseController) sendRespWithInternalError(ctx *gin.Context, data responseData) {
 	log.Errorf("code: %s, err: %s", data.Code, data.Msg)
 
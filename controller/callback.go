@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/xihe-aicc-finetune/app"
+)
+
+func AddRouterForCallbackController(
+	rg *gin.RouterGroup,
+	cs app.CallbackService,
+) {
+	ctl := CallbackController{cs: cs}
+
+	rg.GET("/v1/callback", ctl.List)
+	rg.POST("/v1/callback/:id/replay", ctl.Replay)
+}
+
+type CallbackController struct {
+	baseController
+
+	cs app.CallbackService
+}
+
+//	@Summary		List
+//	@Description	list the callback events waiting to be delivered to the xihe server
+//	@Tags			Callback
+//	@Param			dead	query	bool	false	"only list the dead events"
+//	@Accept			json
+//	@Success		200	{object}		[]app.CallbackEventDTO
+//	@Failure		500	system_error	system	error
+//	@Router			/v1/callback [get]
+func (ctl *CallbackController) List(ctx *gin.Context) {
+	v, err := ctl.cs.List(ctx.Query("dead") == "true")
+	if err != nil {
+		ctl.sendRespWithInternalError(ctx, newResponseError(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newResponseData(v))
+}
+
+//	@Summary		Replay
+//	@Description	deliver the dead callback event again
+//	@Tags			Callback
+//	@Param			id	path	string	true	"id of callback event"
+//	@Accept			json
+//	@Success		202
+//	@Failure		400	bad_request_param	event	can't	be	replayed
+//	@Failure		404	resource_not_exists	event	not	exists
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/callback/{id}/replay [post]
+func (ctl *CallbackController) Replay(ctx *gin.Context) {
+	if err := ctl.cs.Replay(ctx.Param("id")); err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, newResponseData("success"))
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/xihe-aicc-finetune/app"
+)
+
+func AddRouterForPipelineController(
+	rg *gin.RouterGroup,
+	ps app.PipelineService,
+) {
+	ctl := PipelineController{ps: ps}
+
+	rg.POST("/v1/pipeline", ctl.Create)
+	rg.GET("/v1/pipeline/:id", ctl.Get)
+	rg.PUT("/v1/pipeline/:id", ctl.Cancel)
+}
+
+type PipelineController struct {
+	baseController
+
+	ps app.PipelineService
+}
+
+//	@Summary		Create
+//	@Description	create pipeline which runs the finetunes of steps by their dependencies
+//	@Tags			Pipeline
+//	@Param			body	body	PipelineCreateRequest	true	"body of creating pipeline"
+//	@Accept			json
+//	@Success		201	{object}			app.PipelineDTO
+//	@Failure		400	bad_request_body	can't	parse		request	body
+//	@Failure		401	bad_request_param	some	parameter	of		body	is	invalid
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/pipeline [post]
+func (ctl *PipelineController) Create(ctx *gin.Context) {
+	req := PipelineCreateRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, respBadRequestBody)
+
+		return
+	}
+
+	cmd := new(app.PipelineCreateCmd)
+	if err := req.toCmd(cmd); err != nil {
+		ctx.JSON(http.StatusBadRequest, newResponseCodeError(
+			errorBadRequestParam, err,
+		))
+
+		return
+	}
+
+	v, err := ctl.ps.Create(cmd)
+	if err != nil {
+		ctl.sendRespWithInternalError(ctx, newResponseError(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newResponseData(v))
+}
+
+//	@Summary		Get
+//	@Description	get the status of pipeline and its steps
+//	@Tags			Pipeline
+//	@Param			id	path	string	true	"id of pipeline"
+//	@Accept			json
+//	@Success		200	{object}			app.PipelineDTO
+//	@Failure		404	resource_not_exists	pipeline	not	exists
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/pipeline/{id} [get]
+func (ctl *PipelineController) Get(ctx *gin.Context) {
+	v, err := ctl.ps.Get(ctx.Param("id"))
+	if err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newResponseData(v))
+}
+
+//	@Summary		Cancel
+//	@Description	cancel pipeline and terminate its running steps
+//	@Tags			Pipeline
+//	@Param			id	path	string	true	"id of pipeline"
+//	@Accept			json
+//	@Success		202
+//	@Failure		404	resource_not_exists	pipeline	not	exists
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/pipeline/{id} [put]
+func (ctl *PipelineController) Cancel(ctx *gin.Context) {
+	if err := ctl.ps.Cancel(ctx.Param("id")); err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, newResponseData("success"))
+}
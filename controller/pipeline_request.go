@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/opensourceways/xihe-aicc-finetune/app"
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+)
+
+type PipelineCreateRequest struct {
+	PipelineId string                `json:"pipeline_id"`
+	User       string                `json:"user"`
+	Model      string                `json:"model"`
+	Steps      []PipelineStepRequest `json:"steps"`
+}
+
+// PipelineStepRequest is the step of pipeline. The user and model are
+// the ones of pipeline, and the checkpoint is the output of the
+// finetune step it depends on.
+type PipelineStepRequest struct {
+	Step string `json:"step"`
+
+	// DependsOn is the finetune step before it. The step which is not
+	// finetune depends on the last finetune step before it if it is empty.
+	DependsOn []string `json:"depends_on"`
+
+	AICCFinetuneCreateRequest
+}
+
+func (req *PipelineCreateRequest) toCmd(cmd *app.PipelineCreateCmd) (err error) {
+	if cmd.User, err = domain.NewAccount(req.User); err != nil {
+		return
+	}
+
+	if cmd.Model, err = domain.NewModelName(req.Model); err != nil {
+		return
+	}
+
+	cmd.Steps = make([]domain.PipelineStep, len(req.Steps))
+
+	for i := range req.Steps {
+		item := &req.Steps[i]
+
+		if item.FinetuneId != "" || item.Parent != "" ||
+			item.StartAfter != 0 || item.Schedule != "" {
+			err = errors.New("the step can't specify the finetune id, parent or schedule")
+
+			return
+		}
+
+		item.User = req.User
+		item.Model = req.Model
+
+		step := &cmd.Steps[i]
+		step.Name = item.Step
+		step.DependsOn = item.DependsOn
+
+		if err = item.toFinetune(&step.Finetune); err != nil {
+			return
+		}
+
+		if len(step.DependsOn) == 0 && step.Finetune.Task.TaskType() != domain.TaskFinetune {
+			for j := i - 1; j >= 0; j-- {
+				if cmd.Steps[j].Finetune.Task.TaskType() == domain.TaskFinetune {
+					step.DependsOn = []string{cmd.Steps[j].Name}
+
+					break
+				}
+			}
+		}
+	}
+
+	cmd.Id = req.PipelineId
+
+	err = cmd.Validate()
+
+	return
+}
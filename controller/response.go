@@ -1,9 +1,10 @@
 package controller
 
 const (
-	errorSystemError     = "system_error"
-	errorBadRequestBody  = "bad_request_body"
-	errorBadRequestParam = "bad_request_param"
+	errorSystemError       = "system_error"
+	errorBadRequestBody    = "bad_request_body"
+	errorBadRequestParam   = "bad_request_param"
+	errorResourceNotExists = "resource_not_exists"
 )
 
 var (
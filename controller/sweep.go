@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/xihe-aicc-finetune/app"
+)
+
+func AddRouterForSweepController(
+	rg *gin.RouterGroup,
+	ss app.SweepService,
+) {
+	ctl := SweepController{ss: ss}
+
+	rg.POST("/v1/sweep", ctl.Create)
+	rg.GET("/v1/sweep/:id", ctl.Get)
+}
+
+type SweepController struct {
+	baseController
+
+	ss app.SweepService
+}
+
+//	@Summary		Create
+//	@Description	create finetunes with the hyperparameters in the search space
+//	@Tags			Sweep
+//	@Param			body	body	SweepCreateRequest	true	"body of creating sweep"
+//	@Accept			json
+//	@Success		201	{object}			app.SweepDTO
+//	@Failure		400	bad_request_body	can't	parse		request	body
+//	@Failure		401	bad_request_param	some	parameter	of		body	is	invalid
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/sweep [post]
+func (ctl *SweepController) Create(ctx *gin.Context) {
+	req := SweepCreateRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, respBadRequestBody)
+
+		return
+	}
+
+	cmd := new(app.SweepCreateCmd)
+	if err := req.toCmd(cmd); err != nil {
+		ctx.JSON(http.StatusBadRequest, newResponseCodeError(
+			errorBadRequestParam, err,
+		))
+
+		return
+	}
+
+	v, err := ctl.ss.Create(cmd)
+	if err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newResponseData(v))
+}
+
+//	@Summary		Get
+//	@Description	get the statuses and metrics of trials of sweep
+//	@Tags			Sweep
+//	@Param			id	path	string	true	"id of sweep"
+//	@Accept			json
+//	@Success		200	{object}			app.SweepDTO
+//	@Failure		404	resource_not_exists	sweep	not	exists
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/sweep/{id} [get]
+func (ctl *SweepController) Get(ctx *gin.Context) {
+	v, err := ctl.ss.Get(ctx.Param("id"))
+	if err != nil {
+		ctl.sendRespWithError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newResponseData(v))
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"github.com/opensourceways/xihe-aicc-finetune/app"
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+)
+
+type SweepCreateRequest struct {
+	SweepId string `json:"sweep_id"`
+	User    string `json:"user"`
+	Model   string `json:"model"`
+	Name    string `json:"name"`
+	Desc    string `json:"desc"`
+
+	Flavor       string           `json:"flavor"`
+	NodeCount    int              `json:"node_count"`
+	MaxQueueTime int              `json:"max_queue_time"`
+	MaxRunTime   int              `json:"max_run_time"`
+	Retry        *AICCRetryPolicy `json:"retry"`
+
+	// PriorityClass is the class for admission of all the trials.
+	PriorityClass string `json:"priority_class"`
+
+	// Hyperparameters are shared by all the trials.
+	Hyperparameters []AICCKeyValue `json:"hyperparameter"`
+	Env             []AICCKeyValue `json:"env"`
+
+	// Strategy is grid or random.
+	Strategy  string         `json:"strategy"`
+	Space     []SweepParam   `json:"space"`
+	Objective SweepObjective `json:"objective"`
+
+	// MaxTrials is the num of trials of random search and
+	// the limit of trials of grid search if it is not 0.
+	MaxTrials int   `json:"max_trials"`
+	Seed      int64 `json:"seed"`
+}
+
+type SweepParam struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+type SweepObjective struct {
+	Metric   string `json:"metric"`
+	Maximize bool   `json:"maximize"`
+}
+
+func (req *SweepCreateRequest) toCmd(cmd *app.SweepCreateCmd) (err error) {
+	if cmd.User, err = domain.NewAccount(req.User); err != nil {
+		return
+	}
+
+	if cmd.Model, err = domain.NewModelName(req.Model); err != nil {
+		return
+	}
+
+	if cmd.Task, err = domain.NewTaskType(domain.TaskFinetune); err != nil {
+		return
+	}
+
+	if cmd.Name, err = domain.NewFinetuneName(req.Name); err != nil {
+		return
+	}
+
+	if cmd.Desc, err = domain.NewFinetuneDesc(req.Desc); err != nil {
+		return
+	}
+
+	if cmd.Env, err = toKeyValues(req.Env); err != nil {
+		return
+	}
+
+	if cmd.Hyperparameters, err = toKeyValues(req.Hyperparameters); err != nil {
+		return
+	}
+
+	if cmd.Resource, err = domain.NewJobResource(req.Flavor, req.NodeCount); err != nil {
+		return
+	}
+
+	if cmd.Limits, err = domain.NewJobLimits(req.MaxQueueTime, req.MaxRunTime); err != nil {
+		return
+	}
+
+	if req.Retry != nil {
+		if cmd.Retry, err = domain.NewRetryPolicy(req.Retry.MaxRetries, req.Retry.Statuses); err != nil {
+			return
+		}
+	}
+
+	params := make([]domain.SweepParam, len(req.Space))
+	for i := range req.Space {
+		if params[i], err = req.Space[i].toParam(); err != nil {
+			return
+		}
+	}
+
+	cmd.Space, err = domain.NewSearchSpace(req.Strategy, params, req.MaxTrials, req.Seed)
+	if err != nil {
+		return
+	}
+
+	cmd.Objective, err = domain.NewSweepObjective(req.Objective.Metric, req.Objective.Maximize)
+	if err != nil {
+		return
+	}
+
+	cmd.Id = req.SweepId
+	cmd.PriorityClass = req.PriorityClass
+
+	err = cmd.Validate()
+
+	return
+}
+
+func (p *SweepParam) toParam() (r domain.SweepParam, err error) {
+	if r.Key, err = domain.NewCustomizedKey(p.Key); err != nil {
+		return
+	}
+
+	r.Values = make([]domain.CustomizedValue, len(p.Values))
+	for i, v := range p.Values {
+		if r.Values[i], err = domain.NewCustomizedValue(v); err != nil {
+			return
+		}
+	}
+
+	return
+}
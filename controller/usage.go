@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/xihe-aicc-finetune/app"
+)
+
+func AddRouterForUsageController(
+	rg *gin.RouterGroup,
+	us app.UsageService,
+) {
+	ctl := UsageController{us: us}
+
+	rg.GET("/v1/usage", ctl.List)
+}
+
+type UsageController struct {
+	baseController
+
+	us app.UsageService
+}
+
+//	@Summary		List
+//	@Description	list the card hours consumed by the jobs, aggregated by user, model and month
+//	@Tags			Usage
+//	@Param			user	query	string	false	"user"
+//	@Param			model	query	string	false	"model"
+//	@Param			month	query	string	false	"month, such as 2023-05"
+//	@Param			format	query	string	false	"csv to export as csv file"
+//	@Accept			json
+//	@Success		200	{object}			app.UsageReportDTO
+//	@Failure		401	bad_request_param	some	parameter	of	query	is	invalid
+//	@Failure		500	system_error		system	error
+//	@Router			/v1/usage [get]
+func (ctl *UsageController) List(ctx *gin.Context) {
+	req := UsageListRequest{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, newResponseCodeError(
+			errorBadRequestParam, err,
+		))
+
+		return
+	}
+
+	cmd := new(app.UsageListCmd)
+	if err := req.toCmd(cmd); err != nil {
+		ctx.JSON(http.StatusBadRequest, newResponseCodeError(
+			errorBadRequestParam, err,
+		))
+
+		return
+	}
+
+	v, err := ctl.us.List(cmd)
+	if err != nil {
+		ctl.sendRespWithInternalError(ctx, newResponseError(err))
+
+		return
+	}
+
+	if req.Format == usageFormatCSV {
+		writeUsageCSV(ctx, &v)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newResponseData(v))
+}
+
+func writeUsageCSV(ctx *gin.Context, v *app.UsageReportDTO) {
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", `attachment; filename="usage.csv"`)
+	ctx.Status(http.StatusOK)
+
+	w := csv.NewWriter(ctx.Writer)
+
+	_ = w.Write([]string{"month", "user", "model", "jobs", "duration", "card_hours"})
+
+	for i := range v.Items {
+		item := &v.Items[i]
+
+		_ = w.Write([]string{
+			item.Month,
+			item.User,
+			item.Model,
+			strconv.Itoa(item.Jobs),
+			strconv.Itoa(item.Duration),
+			strconv.FormatFloat(item.CardHours, 'f', 2, 64),
+		})
+	}
+
+	w.Flush()
+}
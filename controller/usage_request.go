@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/opensourceways/xihe-aicc-finetune/app"
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+)
+
+const usageFormatCSV = "csv"
+
+type UsageListRequest struct {
+	User   string `form:"user"`
+	Model  string `form:"model"`
+	Month  string `form:"month"`
+	Format string `form:"format"`
+}
+
+func (req *UsageListRequest) toCmd(cmd *app.UsageListCmd) error {
+	if req.User != "" {
+		if _, err := domain.NewAccount(req.User); err != nil {
+			return err
+		}
+	}
+
+	if req.Model != "" {
+		if _, err := domain.NewModelName(req.Model); err != nil {
+			return err
+		}
+	}
+
+	if req.Month != "" && !domain.IsUsageMonth(req.Month) {
+		return errors.New("the month should be in the format of 2006-01")
+	}
+
+	if req.Format != "" && req.Format != usageFormatCSV {
+		return errors.New("unsupported format")
+	}
+
+	*cmd = app.UsageListCmd{
+		User:  req.User,
+		Model: req.Model,
+		Month: req.Month,
+	}
+
+	return nil
+}
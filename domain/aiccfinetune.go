@@ -4,7 +4,28 @@ type AICCFinetune struct {
 	Id    string
 	User  Account
 	Model ModelName
-	Task  string
+	Task  TaskType
+
+	// Parent is the id of finetune whose output is used
+	// as the checkpoint of this one.
+	Parent string
+
+	// Checkpoint is the output dir of parent.
+	Checkpoint string
+
+	// Resume means the finetune continues the training
+	// from the checkpoint of parent.
+	Resume bool
+
+	// Children are the ids of finetunes which use
+	// the output of this one as checkpoint.
+	Children []string
+
+	// Schedule specifies when the job will be submitted.
+	Schedule JobSchedule
+
+	// Pipeline is the id of pipeline which the finetune is a step of.
+	Pipeline string
 
 	AICCFinetuneConfig
 
@@ -18,6 +39,40 @@ type AICCFinetuneConfig struct {
 
 	Hyperparameters []KeyValue
 	Env             []KeyValue
+
+	// EvalSet is the directory of evaluation set
+	// under the data directory of user.
+	EvalSet Directory
+
+	// Inference is the structured prompts of text-to-image inference.
+	Inference *InferenceOption
+
+	Resource JobResource
+
+	// Limits overrides the time limits of model. It can
+	// only be less than the ones of model.
+	Limits JobLimits
+
+	// Retry is the policy of resubmitting the failed job.
+	Retry *RetryPolicy
+
+	// PriorityClass is the class requested for admission.
+	// The class of user or task is used if it is empty.
+	PriorityClass string
+}
+
+// JobLimits specifies the time limits of job. The unit is second
+// and 0 means the limit of model will be used.
+type JobLimits struct {
+	MaxQueueTime int
+	MaxRunTime   int
+}
+
+// JobResource is the resource chosen by user. The empty flavor
+// means the default flavor of model.
+type JobResource struct {
+	Flavor    string
+	NodeCount int
 }
 
 type KeyValue struct {
@@ -36,36 +91,121 @@ type JobInfo struct {
 	JobId     string
 	LogDir    string
 	OutputDir string
+
+	// Pool is the id of pool which the job is put on.
+	Pool string
+
+	// CreatedAt is the unix time when the job is submitted.
+	CreatedAt int64
+
+	// MaxQueueTime and MaxRunTime are the effective time
+	// limits of job. The unit is second.
+	MaxQueueTime int
+	MaxRunTime   int
+
+	// Flavor, NodeCount and CardNum are the effective resource of
+	// job. CardNum is the num of cards of each node.
+	Flavor    string
+	NodeCount int
+	CardNum   int
 }
 
-type JobDetail struct {
+// the policies of handling the unknown jobs found on the platform.
+const (
+	// OrphanPolicyReport only reports the unknown jobs.
+	OrphanPolicyReport = "report"
+
+	// OrphanPolicyAdopt watches the unknown job if its finetune exists
+	// and has no job, for example the finetune failed to save the job.
+	OrphanPolicyAdopt = "adopt"
+
+	// OrphanPolicyTerminate terminates all the unknown jobs.
+	OrphanPolicyTerminate = "terminate"
+)
+
+func IsOrphanPolicy(v string) bool {
+	return v == OrphanPolicyReport || v == OrphanPolicyAdopt || v == OrphanPolicyTerminate
+}
+
+// RemoteJob is the job found on the platform which was created by
+// the service. FinetuneId is parsed from the log dir of job.
+type RemoteJob struct {
+	JobInfo
+
+	Name       string
 	Status     TrainingStatus
-	Error      string
+	FinetuneId string
+}
+
+type JobDetail struct {
+	Status TrainingStatus
+
+	// Error explains why the job failed, and ErrorCategory
+	// is the category of it, such as FailureOOM.
+	Error         string
+	ErrorCategory string
+
+	// TerminationReason is the reason why the service
+	// terminated the job, such as timeout.
+	TerminationReason string
+
 	LogPath    string
 	OutputPath string
 	Duration   int
+	Metrics    Metrics
+	Images     []InferenceImage
+
+	// StartedAt is the unix time when the job started to run
+	// after queuing. It is 0 if the job has not started.
+	StartedAt int64
+
+	// Progress is the latest training progress of the running job,
+	// and ProgressHistory is the recent ones including the latest.
+	Progress        Progress
+	ProgressHistory []Progress
+
+	// Attempts are the previous jobs of the finetune
+	// which were retried.
+	Attempts []JobAttempt
 }
 
-type AICCFinetuneIndex struct {
-	User       Account
-	Model      string
-	FinetuneId string
+// JobAttempt is a finished job of the finetune which was retried.
+type JobAttempt struct {
+	JobId     string
+	Pool      string
+	Status    TrainingStatus
+	Duration  int
+	CreatedAt int64
+	StartedAt int64
 }
 
-func (t *AICCFinetune) DefaultCommand() string {
-	m := map[string]string{
-		"wukong": "python train-lora.py",
+// FinishedAt returns the unix time when the job is done by the start
+// time and duration. The job without the start time, such as the one
+// recorded before, is regarded as started when it was created.
+func FinishedAt(createdAt, startedAt int64, duration int) int64 {
+	if startedAt > 0 {
+		return startedAt + int64(duration)
 	}
-	command := m[t.Model.ModelName()]
 
-	return command
+	return createdAt + int64(duration)
 }
 
-func (t *AICCFinetune) DefaultInferenceCommand() string {
-	m := map[string]string{
-		"wukong": "python txt2img-lora.py",
-	}
-	command := m[t.Model.ModelName()]
+// InferenceImage is the image generated by inference.
+type InferenceImage struct {
+	Path      string
+	Thumbnail string
+}
+
+// Metrics is the scores reported by the job, such as the
+// result of evaluation.
+type Metrics map[string]float64
+
+type AICCFinetuneIndex struct {
+	User       Account
+	Model      string
+	FinetuneId string
+}
 
-	return command
+func (t *AICCFinetune) Command() string {
+	return t.Task.Kind().Command(t.Model.ModelName())
 }
@@ -7,9 +7,15 @@ import (
 type AICCFinetune interface {
 	Create(*domain.AICCFinetune) (domain.JobInfo, error)
 
-	Delete(string) error
+	// Resubmit creates the job again on a pool other than
+	// the one of current job.
+	Resubmit(*domain.AICCFinetune) (domain.JobInfo, error)
+
+	// Render returns the payload of creating the job
+	// without submitting it.
+	Render(*domain.AICCFinetune) ([]byte, error)
 
-	CreateInference(*domain.AICCFinetune) (domain.JobInfo, error)
+	Delete(string) error
 
 	// GetLogDownloadURL returns the log url which can be used
 	// to download the log of running finetune.
@@ -17,16 +23,40 @@ type AICCFinetune interface {
 
 	GetDetail(string) (domain.JobDetail, error)
 
-	// GetLogFilePath return the obs path of log
+	// GetLogFilePath return the obs path of log in the log dir.
+	// It is reported as the log of the finetune which is done.
 	GetLogFilePath(logDir string) (string, error)
 
+	// GetLogTail returns the end of the log file in the log dir.
+	GetLogTail(logDir string) (string, error)
+
 	// GenOutput generates the zip file of output dir and
 	// return the obs path of that file.
 	GenOutput(outputDir string) (string, error)
 
+	// GetMetrics parses the metrics file in the output dir.
+	GetMetrics(outputDir string) (domain.Metrics, error)
+
+	// GetProgress reads the training progress from the progress file
+	// in the output dir, or from the log if the file is not found.
+	GetProgress(outputDir, logDir string) (domain.Progress, error)
+
+	// CollectImages enumerates the images generated in the output dir
+	// and makes the thumbnails for them.
+	CollectImages(outputDir string) ([]domain.InferenceImage, error)
+
+	// FindLatestCheckpoint returns the obs path of the latest
+	// checkpoint in the output dir. It is empty if not found.
+	FindLatestCheckpoint(outputDir string) (string, error)
+
 	// GenFileDownloadURL generate the temprary
 	// download url of obs file.
 	GenFileDownloadURL(p string) (string, error)
 
 	Terminate(string) error
+
+	// ListJobs returns the jobs in the project which are named
+	// by the convention of the service and put their logs under
+	// the log dir of the service.
+	ListJobs() ([]domain.RemoteJob, error)
 }
@@ -0,0 +1,33 @@
+package aiccfinetune
+
+// errorInvalidFile means the file generated by the job can't be
+// parsed, so reading it again will not help.
+type errorInvalidFile struct {
+	error
+}
+
+func NewErrorInvalidFile(err error) errorInvalidFile {
+	return errorInvalidFile{err}
+}
+
+func IsErrorInvalidFile(err error) bool {
+	_, ok := err.(errorInvalidFile)
+
+	return ok
+}
+
+// errorNotAllowed means the job is not allowed by the config, such as
+// the resource which the user is not entitled to use.
+type errorNotAllowed struct {
+	error
+}
+
+func NewErrorNotAllowed(err error) errorNotAllowed {
+	return errorNotAllowed{err}
+}
+
+func IsErrorNotAllowed(err error) bool {
+	_, ok := err.(errorNotAllowed)
+
+	return ok
+}
@@ -20,12 +20,28 @@ var (
 	TrainingStatusCompleted   = trainingStatus("Completed")
 	TrainingStatusTerminated  = trainingStatus("Terminated")
 	TrainingStatusTerminating = trainingStatus("Terminating")
+	TrainingStatusTimeout     = trainingStatus("Timeout")
+	TrainingStatusScheduled   = trainingStatus("Scheduled")
+
+	trainingStatusSet = map[string]TrainingStatus{
+		"Failed":      TrainingStatusFailed,
+		"Pending":     TrainingStatusPending,
+		"Running":     TrainingStatusRunning,
+		"Creating":    TrainingStatusCreating,
+		"Abnormal":    TrainingStatusAbnormal,
+		"Completed":   TrainingStatusCompleted,
+		"Terminated":  TrainingStatusTerminated,
+		"Terminating": TrainingStatusTerminating,
+		"Timeout":     TrainingStatusTimeout,
+		"Scheduled":   TrainingStatusScheduled,
+	}
 
 	trainingDoneStatus = map[string]bool{
 		"Failed":     true,
 		"Abnormal":   true,
 		"Completed":  true,
 		"Terminated": true,
+		"Timeout":    true,
 	}
 )
 
@@ -196,6 +212,14 @@ type TrainingStatus interface {
 	IsSuccess() bool
 }
 
+func NewTrainingStatus(v string) (TrainingStatus, error) {
+	if s, ok := trainingStatusSet[v]; ok {
+		return s, nil
+	}
+
+	return nil, errors.New("unknown training status")
+}
+
 type trainingStatus string
 
 func (s trainingStatus) TrainingStatus() string {
@@ -210,6 +234,48 @@ func (s trainingStatus) IsSuccess() bool {
 	return string(s) == TrainingStatusCompleted.TrainingStatus()
 }
 
+// JobResource
+func NewJobResource(flavor string, nodeCount int) (r JobResource, err error) {
+	if flavor != "" && !reName.MatchString(flavor) {
+		err = errors.New("invalid flavor")
+
+		return
+	}
+
+	if nodeCount < 0 {
+		err = errors.New("invalid node count")
+
+		return
+	}
+
+	if nodeCount == 0 {
+		nodeCount = 1
+	}
+
+	r = JobResource{
+		Flavor:    flavor,
+		NodeCount: nodeCount,
+	}
+
+	return
+}
+
+// JobLimits
+func NewJobLimits(maxQueueTime, maxRunTime int) (r JobLimits, err error) {
+	if maxQueueTime < 0 || maxRunTime < 0 {
+		err = errors.New("invalid time limits")
+
+		return
+	}
+
+	r = JobLimits{
+		MaxQueueTime: maxQueueTime,
+		MaxRunTime:   maxRunTime,
+	}
+
+	return
+}
+
 // ModelName
 type ModelName interface {
 	ModelName() string
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// the categories of the reason why the job failed.
+const (
+	FailureOOM           = "oom"
+	FailureMissingFile   = "missing_file"
+	FailureCommunication = "communication"
+	FailureUserCode      = "user_code"
+	FailurePlatform      = "platform"
+)
+
+const (
+	exitCodeKilled  = 137
+	maxFailureLine  = 200
+	exitCodeSuccess = 0
+)
+
+type failureSignature struct {
+	category string
+	pattern  *regexp.Regexp
+	message  string
+}
+
+// failureSignatures are the known errors in the log of job.
+var failureSignatures = []failureSignature{
+	{
+		category: FailureOOM,
+		pattern:  regexp.MustCompile(`(?i)out of memory|MemoryError|Cannot allocate memory|oom-kill`),
+		message:  "the job ran out of memory, try a smaller batch size or a larger flavor",
+	},
+	{
+		category: FailureMissingFile,
+		pattern:  regexp.MustCompile(`(?i)No such file or directory|FileNotFoundError`),
+		message:  "a file was not found, check the paths of the inputs",
+	},
+	{
+		category: FailureCommunication,
+		pattern:  regexp.MustCompile(`NCCL error|ncclInternalError|ncclSystemError|HCCL.*(?i:error|fail|timeout)`),
+		message:  "the communication between the devices failed",
+	},
+}
+
+// DiagnoseLog finds the known error in the tail of log from the last line.
+// It returns the category and a readable message including the line found.
+func DiagnoseLog(tail string) (category, msg string) {
+	lines := strings.Split(tail, "\n")
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+
+		for j := range failureSignatures {
+			s := &failureSignatures[j]
+
+			if s.pattern.MatchString(line) {
+				if len(line) > maxFailureLine {
+					line = line[:maxFailureLine]
+				}
+
+				return s.category, fmt.Sprintf("%s: %s", s.message, line)
+			}
+		}
+	}
+
+	return
+}
+
+// DiagnoseExitCode explains the exit code of the task of job.
+func DiagnoseExitCode(code int) (category, msg string) {
+	switch code {
+	case exitCodeSuccess:
+		return
+
+	case exitCodeKilled:
+		return FailureOOM, fmt.Sprintf(
+			"exited with code %d, it was killed probably because of out of memory", code,
+		)
+	}
+
+	return FailureUserCode, fmt.Sprintf("exited with code %d", code)
+}
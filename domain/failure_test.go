@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseLog(t *testing.T) {
+	cases := []struct {
+		name     string
+		tail     string
+		category string
+		line     string
+	}{
+		{
+			name:     "out of memory",
+			tail:     "epoch 1\nRuntimeError: CUDA out of memory. Tried to allocate 2.00 GiB\n",
+			category: FailureOOM,
+			line:     "RuntimeError: CUDA out of memory. Tried to allocate 2.00 GiB",
+		},
+		{
+			name:     "missing file",
+			tail:     "FileNotFoundError: [Errno 2] No such file or directory: '/data/train.json'",
+			category: FailureMissingFile,
+			line:     "FileNotFoundError: [Errno 2] No such file or directory: '/data/train.json'",
+		},
+		{
+			name:     "nccl",
+			tail:     "NCCL error in: ProcessGroupNCCL.cpp:1191, unhandled system error",
+			category: FailureCommunication,
+			line:     "NCCL error in: ProcessGroupNCCL.cpp:1191, unhandled system error",
+		},
+		{
+			name:     "hccl",
+			tail:     "[ERROR] HCCL(1234): Timeout waiting for the peer",
+			category: FailureCommunication,
+			line:     "[ERROR] HCCL(1234): Timeout waiting for the peer",
+		},
+		{
+			name:     "the last error found",
+			tail:     "MemoryError\nloading data\nNo such file or directory: ckpt\n\n  \n",
+			category: FailureMissingFile,
+			line:     "No such file or directory: ckpt",
+		},
+		{
+			name:     "long line",
+			tail:     "out of memory " + strings.Repeat("x", maxFailureLine),
+			category: FailureOOM,
+			line:     ("out of memory " + strings.Repeat("x", maxFailureLine))[:maxFailureLine],
+		},
+		{
+			name: "unknown error",
+			tail: "Traceback (most recent call last):\nValueError: bad value",
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for i := range cases {
+		c := &cases[i]
+
+		t.Run(c.name, func(t *testing.T) {
+			category, msg := DiagnoseLog(c.tail)
+
+			if category != c.category {
+				t.Errorf("got category %q, want %q", category, c.category)
+			}
+
+			if c.category == "" {
+				if msg != "" {
+					t.Errorf("got message %q, want empty", msg)
+				}
+
+				return
+			}
+
+			if !strings.HasSuffix(msg, ": "+c.line) {
+				t.Errorf("message %q does not end with the line %q", msg, c.line)
+			}
+		})
+	}
+}
+
+func TestDiagnoseExitCode(t *testing.T) {
+	cases := []struct {
+		code     int
+		category string
+	}{
+		{exitCodeSuccess, ""},
+		{exitCodeKilled, FailureOOM},
+		{1, FailureUserCode},
+	}
+
+	for _, c := range cases {
+		if category, _ := DiagnoseExitCode(c.code); category != c.category {
+			t.Errorf("exit code %d: got category %q, want %q", c.code, category, c.category)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	maxPromptNum       = 8
+	maxPromptLength    = 200
+	maxSampleCount     = 8
+	minResolution      = 256
+	maxResolution      = 1024
+	resolutionStep     = 64
+	defaultSampleCount = 1
+	defaultResolution  = 512
+)
+
+// InferenceOption is the structured input of text-to-image inference.
+type InferenceOption struct {
+	Prompts         []string
+	NegativePrompts []string
+	Seed            int
+	SampleCount     int
+	Width           int
+	Height          int
+}
+
+func NewInferenceOption(
+	prompts, negativePrompts []string,
+	seed, sampleCount, width, height int,
+) (*InferenceOption, error) {
+	if n := len(prompts); n == 0 || n > maxPromptNum {
+		return nil, fmt.Errorf("the number of prompts should be between 1 to %d", maxPromptNum)
+	}
+
+	if n := len(negativePrompts); n != 0 && n != len(prompts) {
+		return nil, errors.New("each prompt should have a negative prompt if any")
+	}
+
+	for i := range prompts {
+		if prompts[i] == "" {
+			return nil, errors.New("empty prompt")
+		}
+	}
+
+	check := func(v []string) error {
+		for _, item := range v {
+			if len([]rune(item)) > maxPromptLength {
+				return fmt.Errorf("the length of prompt should be less than %d", maxPromptLength)
+			}
+		}
+
+		return nil
+	}
+
+	if err := check(prompts); err != nil {
+		return nil, err
+	}
+
+	if err := check(negativePrompts); err != nil {
+		return nil, err
+	}
+
+	if seed < 0 {
+		return nil, errors.New("seed should not be negative")
+	}
+
+	if sampleCount == 0 {
+		sampleCount = defaultSampleCount
+	}
+
+	if sampleCount < 0 || sampleCount > maxSampleCount {
+		return nil, fmt.Errorf("sample count should be between 1 to %d", maxSampleCount)
+	}
+
+	w, err := checkResolution(width)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := checkResolution(height)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InferenceOption{
+		Prompts:         prompts,
+		NegativePrompts: negativePrompts,
+		Seed:            seed,
+		SampleCount:     sampleCount,
+		Width:           w,
+		Height:          h,
+	}, nil
+}
+
+func checkResolution(v int) (int, error) {
+	if v == 0 {
+		return defaultResolution, nil
+	}
+
+	if v < minResolution || v > maxResolution || v%resolutionStep != 0 {
+		return 0, fmt.Errorf(
+			"resolution should be a multiple of %d between %d to %d",
+			resolutionStep, minResolution, maxResolution,
+		)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+const maxPipelineSteps = 10
+
+// Pipeline runs the finetunes of steps by their dependencies.
+type Pipeline struct {
+	Id    string
+	User  Account
+	Model ModelName
+	Steps []PipelineStep
+
+	// Canceled means the steps which are not submitted
+	// will not be submitted any more.
+	Canceled bool
+
+	// Settled means all the steps are submitted or failed,
+	// so the pipeline needs not be advanced any more.
+	Settled bool
+
+	CreatedAt int64
+}
+
+// PipelineStep is a finetune which will be submitted after the step
+// it depends on completed successfully. The step can only depend on a
+// finetune step, and Finetune.Parent is that step whose output is the
+// checkpoint of this step.
+type PipelineStep struct {
+	Name      string
+	DependsOn []string
+	Finetune  AICCFinetune
+
+	// Error is the reason why the finetune is not created.
+	Error string
+
+	// Attempts is the num of failures of creating the finetune.
+	Attempts int
+}
+
+// StepFinetuneId returns the id of finetune of the step.
+func StepFinetuneId(pipelineId, step string) string {
+	return pipelineId + "-" + step
+}
+
+// ValidatePipelineSteps checks the dependencies of steps. The step
+// can only depend on the steps before it, so there is no cycle.
+func ValidatePipelineSteps(steps []PipelineStep) error {
+	if n := len(steps); n == 0 || n > maxPipelineSteps {
+		return fmt.Errorf("the num of steps should be between 1 to %d", maxPipelineSteps)
+	}
+
+	index := make(map[string]int, len(steps))
+
+	for i := range steps {
+		item := &steps[i]
+
+		if !reName.MatchString(item.Name) {
+			return errors.New("invalid name of step")
+		}
+
+		if _, ok := index[item.Name]; ok {
+			return fmt.Errorf("duplicate step: %s", item.Name)
+		}
+
+		for _, dep := range item.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return fmt.Errorf(
+					"step %s should depend on the steps before it", item.Name,
+				)
+			}
+		}
+
+		index[item.Name] = i
+	}
+
+	return nil
+}
+
+// Step returns the step of name.
+func (p *Pipeline) Step(name string) *PipelineStep {
+	for i := range p.Steps {
+		if p.Steps[i].Name == name {
+			return &p.Steps[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package domain
+
+import "testing"
+
+func TestValidatePipelineSteps(t *testing.T) {
+	step := func(name string, deps ...string) PipelineStep {
+		return PipelineStep{Name: name, DependsOn: deps}
+	}
+
+	many := make([]PipelineStep, maxPipelineSteps+1)
+	for i := range many {
+		many[i] = step(string(rune('a' + i)))
+	}
+
+	cases := []struct {
+		name  string
+		steps []PipelineStep
+		valid bool
+	}{
+		{
+			name:  "single step",
+			steps: []PipelineStep{step("train")},
+			valid: true,
+		},
+		{
+			name:  "depend on the steps before",
+			steps: []PipelineStep{step("train"), step("eval", "train"), step("infer", "train", "eval")},
+			valid: true,
+		},
+		{
+			name:  "max steps",
+			steps: many[:maxPipelineSteps],
+			valid: true,
+		},
+		{
+			name: "no step",
+		},
+		{
+			name:  "too many steps",
+			steps: many,
+		},
+		{
+			name:  "invalid name",
+			steps: []PipelineStep{step("train/1")},
+		},
+		{
+			name:  "duplicate step",
+			steps: []PipelineStep{step("train"), step("train")},
+		},
+		{
+			name:  "depend on the step after",
+			steps: []PipelineStep{step("eval", "train"), step("train")},
+		},
+		{
+			name:  "depend on itself",
+			steps: []PipelineStep{step("train", "train")},
+		},
+		{
+			name:  "depend on unknown step",
+			steps: []PipelineStep{step("train"), step("eval", "test")},
+		},
+	}
+
+	for i := range cases {
+		c := &cases[i]
+
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidatePipelineSteps(c.steps)
+
+			if c.valid && err != nil {
+				t.Errorf("unexpected error:%s", err.Error())
+			}
+
+			if !c.valid && err == nil {
+				t.Error("expect error")
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxProgressHistory is the num of progresses kept in the history.
+const MaxProgressHistory = 60
+
+var (
+	progressStep  = regexp.MustCompile(`(?i)\bstep[\s:=]+(\d+)\s*/\s*(\d+)`)
+	progressEpoch = regexp.MustCompile(`(?i)\bepoch[\s:=]+(\d+)(?:\s*/\s*(\d+))?`)
+	progressLoss  = regexp.MustCompile(`(?i)\bloss[\s:=]+([-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?)`)
+	progressETA   = regexp.MustCompile(`(?i)\beta[\s:=]+(\d+(?::\d{1,2}){0,2})`)
+)
+
+// Progress is the training progress reported by the job.
+type Progress struct {
+	Step        int
+	TotalSteps  int
+	Epoch       int
+	TotalEpochs int
+	Loss        float64
+
+	// ETA is the estimated seconds before the training ends.
+	ETA int
+
+	// UpdatedAt is the unix time when the progress is found.
+	UpdatedAt int64
+}
+
+func (p *Progress) IsEmpty() bool {
+	return p.Step == 0 && p.TotalSteps == 0 && p.Epoch == 0
+}
+
+// Percent returns the percent of steps done. It is -1 if unknown.
+func (p *Progress) Percent() int {
+	if p.TotalSteps <= 0 {
+		return -1
+	}
+
+	if p.Step >= p.TotalSteps {
+		return 100
+	}
+
+	return p.Step * 100 / p.TotalSteps
+}
+
+// UpdateProgress sets the latest progress and appends it to the history
+// if the training advanced. It returns false if nothing changed.
+func (d *JobDetail) UpdateProgress(p Progress) bool {
+	if p.IsEmpty() || (p.Step == d.Progress.Step && p.Epoch == d.Progress.Epoch) {
+		return false
+	}
+
+	d.Progress = p
+
+	if n := len(d.ProgressHistory); n >= MaxProgressHistory {
+		d.ProgressHistory = append(d.ProgressHistory[:0], d.ProgressHistory[n-MaxProgressHistory+1:]...)
+	}
+	d.ProgressHistory = append(d.ProgressHistory, p)
+
+	return true
+}
+
+// ParseProgressLog finds the last line of log which reports the progress,
+// such as "epoch: 1/3, step: 100/1000, loss: 0.35, eta: 00:10:20".
+// The line must have the step and total steps, and the others are optional.
+func ParseProgressLog(tail string) (p Progress, ok bool) {
+	lines := strings.Split(tail, "\n")
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+
+		m := progressStep.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		p.Step, _ = strconv.Atoi(m[1])
+		p.TotalSteps, _ = strconv.Atoi(m[2])
+
+		if m = progressEpoch.FindStringSubmatch(line); m != nil {
+			p.Epoch, _ = strconv.Atoi(m[1])
+			p.TotalEpochs, _ = strconv.Atoi(m[2])
+		}
+
+		if m = progressLoss.FindStringSubmatch(line); m != nil {
+			p.Loss, _ = strconv.ParseFloat(m[1], 64)
+		}
+
+		if m = progressETA.FindStringSubmatch(line); m != nil {
+			p.ETA = parseDuration(m[1])
+		}
+
+		return p, true
+	}
+
+	return
+}
+
+// parseDuration parses the duration in the format of
+// seconds, minutes:seconds or hours:minutes:seconds.
+func parseDuration(s string) (r int) {
+	for _, v := range strings.Split(s, ":") {
+		n, _ := strconv.Atoi(v)
+		r = r*60 + n
+	}
+
+	return
+}
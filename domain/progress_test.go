@@ -0,0 +1,100 @@
+package domain
+
+import "testing"
+
+func TestParseProgressLog(t *testing.T) {
+	cases := []struct {
+		name string
+		tail string
+		want Progress
+		ok   bool
+	}{
+		{
+			name: "all fields",
+			tail: "epoch: 1/3, step: 100/1000, loss: 0.35, eta: 00:10:20",
+			want: Progress{Step: 100, TotalSteps: 1000, Epoch: 1, TotalEpochs: 3, Loss: 0.35, ETA: 620},
+			ok:   true,
+		},
+		{
+			name: "only steps",
+			tail: "Step 5 / 50",
+			want: Progress{Step: 5, TotalSteps: 50},
+			ok:   true,
+		},
+		{
+			name: "the last line reporting progress",
+			tail: "step=1/10 loss=1.5\nstep=2/10 loss=1.2\nsaving checkpoint\n",
+			want: Progress{Step: 2, TotalSteps: 10, Loss: 1.2},
+			ok:   true,
+		},
+		{
+			name: "epoch without total and loss in exponent",
+			tail: "Epoch 2 Step 7/20 Loss 1.5e-3 ETA 90",
+			want: Progress{Step: 7, TotalSteps: 20, Epoch: 2, Loss: 0.0015, ETA: 90},
+			ok:   true,
+		},
+		{
+			name: "eta of minutes and seconds",
+			tail: "step: 3/9 eta: 2:05",
+			want: Progress{Step: 3, TotalSteps: 9, ETA: 125},
+			ok:   true,
+		},
+		{
+			name: "step without total",
+			tail: "step: 3, loss: 0.1",
+		},
+		{
+			name: "not a word of step",
+			tail: "substep: 3/9",
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for i := range cases {
+		c := &cases[i]
+
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := ParseProgressLog(c.tail)
+
+			if ok != c.ok {
+				t.Fatalf("got ok %t, want %t", ok, c.ok)
+			}
+
+			if got != c.want {
+				t.Errorf("got %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestUpdateProgress(t *testing.T) {
+	d := new(JobDetail)
+
+	if d.UpdateProgress(Progress{}) {
+		t.Error("empty progress should not be updated")
+	}
+
+	for i := 1; i <= MaxProgressHistory+5; i++ {
+		if !d.UpdateProgress(Progress{Step: i, TotalSteps: 100}) {
+			t.Fatalf("progress of step %d should be updated", i)
+		}
+	}
+
+	if d.UpdateProgress(Progress{Step: MaxProgressHistory + 5, TotalSteps: 100}) {
+		t.Error("the same progress should not be updated")
+	}
+
+	if n := len(d.ProgressHistory); n != MaxProgressHistory {
+		t.Fatalf("got %d progresses in history, want %d", n, MaxProgressHistory)
+	}
+
+	if first := d.ProgressHistory[0].Step; first != 6 {
+		t.Errorf("got the first step %d in history, want 6", first)
+	}
+
+	if d.Progress.Step != MaxProgressHistory+5 {
+		t.Errorf("got the latest step %d, want %d", d.Progress.Step, MaxProgressHistory+5)
+	}
+}
@@ -0,0 +1,18 @@
+package repository
+
+import "github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+
+// AdmissionLedger records the finetunes admitted to watch by all
+// the replicas, so that the max watch num is enforced across them.
+type AdmissionLedger interface {
+	// Admit records the finetune if admit returns true with the admitted
+	// ones. It is not interleaved with the other replicas. It returns true
+	// without calling admit if the finetune has been recorded.
+	Admit(
+		finetuneId string, r *watch.AdmissionRecord,
+		admit func(map[string]watch.AdmissionRecord) bool,
+	) (bool, error)
+
+	Release(finetuneId string) error
+	FindAll() (map[string]watch.AdmissionRecord, error)
+}
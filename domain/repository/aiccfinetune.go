@@ -0,0 +1,21 @@
+package repository
+
+import "github.com/opensourceways/xihe-aicc-finetune/domain"
+
+type AICCFinetune interface {
+	Save(*domain.AICCFinetune) error
+	Get(finetuneId string) (domain.AICCFinetune, error)
+	UpdateJob(finetuneId string, job *domain.JobInfo) error
+	UpdateDetail(finetuneId string, detail *domain.JobDetail) error
+	AddChild(finetuneId, childId string) error
+
+	// FindAll returns all the finetunes. It is expensive
+	// and should only be used by the maintenance work.
+	FindAll() ([]domain.AICCFinetune, error)
+
+	// AddScheduled records the finetune which will be submitted at submitAt.
+	AddScheduled(finetuneId string, submitAt int64) error
+	// FindScheduled returns the ids of finetune which should be submitted before.
+	FindScheduled(before int64) ([]string, error)
+	RemoveScheduled(finetuneId string) error
+}
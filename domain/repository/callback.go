@@ -0,0 +1,10 @@
+package repository
+
+import "github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+
+type CallbackOutbox interface {
+	Save(*watch.CallbackEvent) error
+	Get(eventId string) (watch.CallbackEvent, error)
+	FindAll() ([]watch.CallbackEvent, error)
+	Remove(eventId string) error
+}
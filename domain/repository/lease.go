@@ -0,0 +1,14 @@
+package repository
+
+import "time"
+
+// Lease is held by one replica until it expires, so that
+// only one replica does the work guarded by the lease.
+type Lease interface {
+	// TryAcquire acquires or renews the lease for the holder. It returns
+	// true if the holder holds the lease for the duration from now on.
+	TryAcquire(name, holder string, duration time.Duration) (bool, error)
+
+	// Release gives up the lease if the holder holds it.
+	Release(name, holder string) error
+}
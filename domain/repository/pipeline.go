@@ -0,0 +1,14 @@
+package repository
+
+import "github.com/opensourceways/xihe-aicc-finetune/domain"
+
+type Pipeline interface {
+	Save(*domain.Pipeline) error
+	Get(pipelineId string) (domain.Pipeline, error)
+	FindAll() ([]domain.Pipeline, error)
+
+	// Update changes the pipeline by f and saves it. The pipeline
+	// is not changed by the others, such as the other replicas,
+	// until f returns.
+	Update(pipelineId string, f func(*domain.Pipeline) error) error
+}
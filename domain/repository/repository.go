@@ -0,0 +1,15 @@
+package repository
+
+type errorResourceNotExists struct {
+	error
+}
+
+func NewErrorResourceNotExists(err error) errorResourceNotExists {
+	return errorResourceNotExists{err}
+}
+
+func IsErrorResourceNotExists(err error) bool {
+	_, ok := err.(errorResourceNotExists)
+
+	return ok
+}
@@ -0,0 +1,8 @@
+package repository
+
+import "github.com/opensourceways/xihe-aicc-finetune/domain"
+
+type Sweep interface {
+	Save(*domain.Sweep) error
+	Get(sweepId string) (domain.Sweep, error)
+}
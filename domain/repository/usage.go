@@ -0,0 +1,10 @@
+package repository
+
+import "github.com/opensourceways/xihe-aicc-finetune/domain"
+
+type Usage interface {
+	// Save saves the usage of job. It replaces the
+	// one of the same job if exists.
+	Save(*domain.Usage) error
+	FindAll() ([]domain.Usage, error)
+}
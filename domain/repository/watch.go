@@ -0,0 +1,9 @@
+package repository
+
+import "github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+
+type WatchState interface {
+	Save(*watch.WatchState) error
+	FindAll() ([]watch.WatchState, error)
+	Remove(finetuneId string) error
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+const maxRetries = 3
+
+var retryableStatus = map[string]bool{
+	"Failed":   true,
+	"Abnormal": true,
+}
+
+// RetryPolicy specifies when and how many times the job
+// should be resubmitted if it ends unsuccessfully.
+type RetryPolicy struct {
+	MaxRetries int
+	Statuses   []TrainingStatus
+}
+
+// NewRetryPolicy returns the policy. It retries the abnormal job
+// only if statuses is empty.
+func NewRetryPolicy(max int, statuses []string) (*RetryPolicy, error) {
+	if max <= 0 || max > maxRetries {
+		return nil, fmt.Errorf("max retries should be between 1 to %d", maxRetries)
+	}
+
+	if len(statuses) == 0 {
+		statuses = []string{TrainingStatusAbnormal.TrainingStatus()}
+	}
+
+	r := &RetryPolicy{
+		MaxRetries: max,
+		Statuses:   make([]TrainingStatus, len(statuses)),
+	}
+
+	for i, v := range statuses {
+		if !retryableStatus[v] {
+			return nil, errors.New("only failed or abnormal job can be retried")
+		}
+
+		r.Statuses[i] = trainingStatus(v)
+	}
+
+	return r, nil
+}
+
+// ShouldRetry returns true if the job ends with status
+// can be retried after the specified num of retries.
+func (p *RetryPolicy) ShouldRetry(status TrainingStatus, retried int) bool {
+	if p == nil || retried >= p.MaxRetries {
+		return false
+	}
+
+	for _, v := range p.Statuses {
+		if v == status {
+			return true
+		}
+	}
+
+	return false
+}
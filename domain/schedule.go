@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxScheduleDelay is the max time that a job can be deferred.
+const maxScheduleDelay = 30 * 24 * time.Hour
+
+// JobSchedule specifies when the job will be submitted.
+type JobSchedule struct {
+	// Cron is the expression of 5 fields, such as "0 2 * * *",
+	// which means the job will be submitted at the first time
+	// matching it.
+	Cron string
+
+	// SubmitAt is the unix time when the job will be submitted.
+	// 0 means the job is submitted immediately.
+	SubmitAt int64
+}
+
+func (s *JobSchedule) IsScheduled() bool {
+	return s.SubmitAt > 0
+}
+
+// NewJobSchedule returns the schedule by which the job will be
+// submitted after startAfter and at the time matching cron.
+func NewJobSchedule(startAfter int64, cron string, now time.Time) (s JobSchedule, err error) {
+	if startAfter < 0 {
+		err = errors.New("invalid start time")
+
+		return
+	}
+
+	t := now
+	if v := time.Unix(startAfter, 0); v.After(t) {
+		t = v
+	} else if cron == "" {
+		return
+	}
+
+	if cron != "" {
+		c, err1 := parseCron(cron)
+		if err1 != nil {
+			err = err1
+
+			return
+		}
+
+		if t, err = c.next(t.Add(-time.Second), now.Add(maxScheduleDelay)); err != nil {
+			return
+		}
+	}
+
+	if t.Sub(now) > maxScheduleDelay {
+		err = fmt.Errorf("the job can't be deferred more than %s", maxScheduleDelay)
+
+		return
+	}
+
+	s = JobSchedule{Cron: cron, SubmitAt: t.Unix()}
+
+	return
+}
+
+// cronExpr is the parsed cron expression of minute,
+// hour, day of month, month and day of week.
+type cronExpr struct {
+	minute, hour, dom, month, dow map[int]bool
+}
+
+func parseCron(v string) (c cronExpr, err error) {
+	fields := strings.Fields(v)
+	if len(fields) != 5 {
+		err = errors.New("cron should have 5 fields")
+
+		return
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	items := [5]*map[int]bool{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+
+	for i, f := range fields {
+		if *items[i], err = parseCronField(f, bounds[i][0], bounds[i][1]); err != nil {
+			err = fmt.Errorf("invalid cron field %s, err:%s", f, err.Error())
+
+			return
+		}
+	}
+
+	return
+}
+
+// parseCronField parses the field which consists of
+// *, n, n-m and their steps separated by comma.
+func parseCronField(v string, min, max int) (map[int]bool, error) {
+	r := map[int]bool{}
+
+	for _, item := range strings.Split(v, ",") {
+		step := 1
+
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return nil, errors.New("invalid step")
+			}
+
+			step = n
+			item = item[:i]
+		}
+
+		low, high := min, max
+
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+
+			n, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, err
+			}
+
+			low, high = n, n
+
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return nil, errors.New("out of range")
+		}
+
+		for i := low; i <= high; i += step {
+			r[i] = true
+		}
+	}
+
+	return r, nil
+}
+
+// next returns the first time which is after t and matches the cron.
+func (c *cronExpr) next(t, end time.Time) (time.Time, error) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for !t.After(end) {
+		switch {
+		case !c.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+
+		case !c.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+
+		default:
+			return t, nil
+		}
+	}
+
+	return t, errors.New("no time matches the cron in the max schedule delay")
+}
+
+// matchDay matches either the day of month or the day of week
+// if both of them are restricted, as the cron does.
+func (c *cronExpr) matchDay(t time.Time) bool {
+	dom := c.dom[t.Day()]
+	dow := c.dow[int(t.Weekday())]
+
+	if len(c.dom) < 31 && len(c.dow) < 7 {
+		return dom || dow
+	}
+
+	return dom && dow
+}
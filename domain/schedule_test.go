@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	date := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name string
+		cron string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "next minute",
+			cron: "* * * * *",
+			from: date(2023, 5, 10, 10, 7),
+			want: date(2023, 5, 10, 10, 8),
+		},
+		{
+			name: "after the time matched",
+			cron: "0 0 * * *",
+			from: date(2023, 5, 10, 0, 0),
+			want: date(2023, 5, 11, 0, 0),
+		},
+		{
+			name: "month boundary",
+			cron: "0 0 1 * *",
+			from: date(2023, 1, 31, 10, 0),
+			want: date(2023, 2, 1, 0, 0),
+		},
+		{
+			name: "year boundary",
+			cron: "30 6 1 1 *",
+			from: date(2023, 12, 31, 23, 59),
+			want: date(2024, 1, 1, 6, 30),
+		},
+		{
+			name: "leap day",
+			cron: "0 0 29 2 *",
+			from: date(2023, 3, 1, 0, 0),
+			want: date(2024, 2, 29, 0, 0),
+		},
+		{
+			name: "skip the month without the day",
+			cron: "0 0 31 * *",
+			from: date(2023, 4, 1, 0, 0),
+			want: date(2023, 5, 31, 0, 0),
+		},
+		{
+			name: "list of months",
+			cron: "0 0 1 3,6 *",
+			from: date(2023, 4, 2, 0, 0),
+			want: date(2023, 6, 1, 0, 0),
+		},
+		{
+			name: "step of minutes",
+			cron: "*/15 * * * *",
+			from: date(2023, 5, 10, 10, 7),
+			want: date(2023, 5, 10, 10, 15),
+		},
+		{
+			name: "step of range",
+			cron: "0 9-17/4 * * *",
+			from: date(2023, 5, 10, 13, 0),
+			want: date(2023, 5, 10, 17, 0),
+		},
+		{
+			name: "step of range to next day",
+			cron: "0 9-17/4 * * *",
+			from: date(2023, 5, 10, 18, 0),
+			want: date(2023, 5, 11, 9, 0),
+		},
+		{
+			name: "range of weekdays",
+			cron: "0 0 * * 1-5",
+			from: date(2023, 5, 13, 12, 0),
+			want: date(2023, 5, 15, 0, 0),
+		},
+		{
+			name: "day of month when both restricted",
+			cron: "0 0 13 * 1",
+			from: date(2023, 5, 10, 0, 0),
+			want: date(2023, 5, 13, 0, 0),
+		},
+		{
+			name: "day of week when both restricted",
+			cron: "0 0 13 * 1",
+			from: date(2023, 5, 14, 0, 0),
+			want: date(2023, 5, 15, 0, 0),
+		},
+		{
+			name: "day of month when day of week is any",
+			cron: "0 0 13 * *",
+			from: date(2023, 5, 14, 0, 0),
+			want: date(2023, 6, 13, 0, 0),
+		},
+	}
+
+	for i := range cases {
+		c := &cases[i]
+
+		t.Run(c.name, func(t *testing.T) {
+			expr, err := parseCron(c.cron)
+			if err != nil {
+				t.Fatalf("parse cron failed, err:%s", err.Error())
+			}
+
+			got, err := expr.next(c.from, c.from.AddDate(2, 0, 0))
+			if err != nil {
+				t.Fatalf("next failed, err:%s", err.Error())
+			}
+
+			if !got.Equal(c.want) {
+				t.Errorf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCronNextNoMatch(t *testing.T) {
+	expr, err := parseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("parse cron failed, err:%s", err.Error())
+	}
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := expr.next(from, from.AddDate(1, 0, 0)); err == nil {
+		t.Error("expect error for the cron matching no time")
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, v := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := parseCron(v); err == nil {
+			t.Errorf("expect error for cron %q", v)
+		}
+	}
+}
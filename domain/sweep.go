@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	SweepStrategyGrid   = "grid"
+	SweepStrategyRandom = "random"
+
+	maxSweepTrials = 32
+)
+
+// Sweep fans out the finetunes with the combinations of
+// hyperparameters in the search space.
+type Sweep struct {
+	Id    string
+	User  Account
+	Model ModelName
+	Task  TaskType
+
+	// AICCFinetuneConfig is the config shared by all the trials.
+	AICCFinetuneConfig
+
+	Space     SearchSpace
+	Objective SweepObjective
+	Trials    []SweepTrial
+	CreatedAt int64
+}
+
+// SweepTrial is a finetune of sweep.
+type SweepTrial struct {
+	FinetuneId      string
+	Hyperparameters []KeyValue
+
+	// Error is the reason why the finetune is not created.
+	Error string
+}
+
+type SweepParam struct {
+	Key    CustomizedKey
+	Values []CustomizedValue
+}
+
+// SearchSpace is the discrete values of hyperparameters. Grid tries
+// all the combinations and random tries MaxTrials ones of them.
+type SearchSpace struct {
+	Strategy  string
+	Params    []SweepParam
+	MaxTrials int
+	Seed      int64
+}
+
+func NewSearchSpace(strategy string, params []SweepParam, maxTrials int, seed int64) (
+	s SearchSpace, err error,
+) {
+	if strategy != SweepStrategyGrid && strategy != SweepStrategyRandom {
+		err = fmt.Errorf("unknown strategy: %s", strategy)
+
+		return
+	}
+
+	if len(params) == 0 {
+		err = errors.New("missing params of search space")
+
+		return
+	}
+
+	total := 1
+	keys := make(map[string]bool, len(params))
+
+	for i := range params {
+		item := &params[i]
+
+		if item.Key == nil || len(item.Values) == 0 {
+			err = errors.New("invalid param of search space")
+
+			return
+		}
+
+		for _, v := range item.Values {
+			if v == nil {
+				err = errors.New("empty value of search space")
+
+				return
+			}
+		}
+
+		k := item.Key.CustomizedKey()
+		if keys[k] {
+			err = fmt.Errorf("duplicate param: %s", k)
+
+			return
+		}
+		keys[k] = true
+
+		if total *= len(item.Values); total > maxSweepTrials*maxSweepTrials {
+			err = errors.New("the search space is too large")
+
+			return
+		}
+	}
+
+	if maxTrials < 0 || maxTrials > maxSweepTrials {
+		err = fmt.Errorf("max trials should be between 0 to %d", maxSweepTrials)
+
+		return
+	}
+
+	if strategy == SweepStrategyGrid {
+		if total > maxSweepTrials || (maxTrials > 0 && total > maxTrials) {
+			err = errors.New("the grid exceeds the max trials")
+
+			return
+		}
+	} else if maxTrials == 0 {
+		err = errors.New("random search needs max trials")
+
+		return
+	}
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	s = SearchSpace{
+		Strategy:  strategy,
+		Params:    params,
+		MaxTrials: maxTrials,
+		Seed:      seed,
+	}
+
+	return
+}
+
+// Combinations returns the hyperparameters of each trial.
+func (s *SearchSpace) Combinations() [][]KeyValue {
+	total := 1
+	for i := range s.Params {
+		total *= len(s.Params[i].Values)
+	}
+
+	indexes := make([]int, total)
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	if s.Strategy == SweepStrategyRandom {
+		rand.New(rand.NewSource(s.Seed)).Shuffle(total, func(i, j int) {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		})
+
+		if total > s.MaxTrials {
+			indexes = indexes[:s.MaxTrials]
+		}
+	}
+
+	r := make([][]KeyValue, len(indexes))
+	for i, n := range indexes {
+		kv := make([]KeyValue, len(s.Params))
+
+		for j := len(s.Params) - 1; j >= 0; j-- {
+			p := &s.Params[j]
+			m := len(p.Values)
+
+			kv[j] = KeyValue{Key: p.Key, Value: p.Values[n%m]}
+			n /= m
+		}
+
+		r[i] = kv
+	}
+
+	return r
+}
+
+// SweepObjective specifies the metric to compare the trials.
+type SweepObjective struct {
+	Metric   string
+	Maximize bool
+}
+
+func NewSweepObjective(metric string, maximize bool) (SweepObjective, error) {
+	if metric == "" || !reName.MatchString(metric) {
+		return SweepObjective{}, errors.New("invalid metric of objective")
+	}
+
+	return SweepObjective{Metric: metric, Maximize: maximize}, nil
+}
+
+// Better returns true if the metric a is better than b.
+func (o *SweepObjective) Better(a, b float64) bool {
+	if o.Maximize {
+		return a > b
+	}
+
+	return a < b
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newSweepParam(t *testing.T, key string, values ...string) SweepParam {
+	k, err := NewCustomizedKey(key)
+	if err != nil {
+		t.Fatalf("new key failed, err:%s", err.Error())
+	}
+
+	p := SweepParam{Key: k, Values: make([]CustomizedValue, len(values))}
+	for i, v := range values {
+		if p.Values[i], err = NewCustomizedValue(v); err != nil {
+			t.Fatalf("new value failed, err:%s", err.Error())
+		}
+	}
+
+	return p
+}
+
+// combinationStrings returns the combinations such as "lr=0.1,bs=8".
+func combinationStrings(v [][]KeyValue) []string {
+	r := make([]string, len(v))
+	for i, kvs := range v {
+		items := make([]string, len(kvs))
+		for j := range kvs {
+			items[j] = kvs[j].Key.CustomizedKey() + "=" + kvs[j].Value.CustomizedValue()
+		}
+
+		r[i] = strings.Join(items, ",")
+	}
+
+	return r
+}
+
+func TestSearchSpaceGrid(t *testing.T) {
+	s, err := NewSearchSpace(SweepStrategyGrid, []SweepParam{
+		newSweepParam(t, "lr", "0.1", "0.01"),
+		newSweepParam(t, "bs", "8", "16", "32"),
+	}, 0, 1)
+	if err != nil {
+		t.Fatalf("new search space failed, err:%s", err.Error())
+	}
+
+	want := []string{
+		"lr=0.1,bs=8", "lr=0.1,bs=16", "lr=0.1,bs=32",
+		"lr=0.01,bs=8", "lr=0.01,bs=16", "lr=0.01,bs=32",
+	}
+
+	if got := combinationStrings(s.Combinations()); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSearchSpaceRandom(t *testing.T) {
+	params := []SweepParam{
+		newSweepParam(t, "lr", "0.1", "0.01", "0.001"),
+		newSweepParam(t, "bs", "8", "16", "32"),
+	}
+
+	s, err := NewSearchSpace(SweepStrategyRandom, params, 4, 42)
+	if err != nil {
+		t.Fatalf("new search space failed, err:%s", err.Error())
+	}
+
+	got := combinationStrings(s.Combinations())
+	if len(got) != 4 {
+		t.Fatalf("got %d trials, want 4", len(got))
+	}
+
+	seen := map[string]bool{}
+	for _, v := range got {
+		if seen[v] {
+			t.Errorf("duplicate trial %s", v)
+		}
+		seen[v] = true
+	}
+
+	if again := combinationStrings(s.Combinations()); !reflect.DeepEqual(got, again) {
+		t.Errorf("the trials of the same seed differ, %v and %v", got, again)
+	}
+
+	s.MaxTrials = 20
+	if n := len(s.Combinations()); n != 9 {
+		t.Errorf("got %d trials, want all the 9 combinations", n)
+	}
+}
+
+func TestNewSearchSpaceInvalid(t *testing.T) {
+	lr := newSweepParam(t, "lr", "0.1", "0.01")
+
+	many := make([]string, maxSweepTrials+1)
+	for i := range many {
+		many[i] = strings.Repeat("1", i+1)
+	}
+
+	cases := []struct {
+		name      string
+		strategy  string
+		params    []SweepParam
+		maxTrials int
+	}{
+		{"unknown strategy", "bayes", []SweepParam{lr}, 0},
+		{"no param", SweepStrategyGrid, nil, 0},
+		{"no value", SweepStrategyGrid, []SweepParam{{Key: lr.Key}}, 0},
+		{"duplicate param", SweepStrategyGrid, []SweepParam{lr, lr}, 0},
+		{"negative max trials", SweepStrategyGrid, []SweepParam{lr}, -1},
+		{"too many max trials", SweepStrategyRandom, []SweepParam{lr}, maxSweepTrials + 1},
+		{"grid exceeds max trials", SweepStrategyGrid, []SweepParam{lr}, 1},
+		{"grid too large", SweepStrategyGrid, []SweepParam{newSweepParam(t, "bs", many...)}, 0},
+		{"random without max trials", SweepStrategyRandom, []SweepParam{lr}, 0},
+	}
+
+	for i := range cases {
+		c := &cases[i]
+
+		if _, err := NewSearchSpace(c.strategy, c.params, c.maxTrials, 1); err == nil {
+			t.Errorf("%s: expect error", c.name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package domain
+
+import "errors"
+
+const (
+	TaskFinetune  = "finetune"
+	TaskInference = "inference"
+	TaskEvaluate  = "evaluate"
+
+	// input sources which will be resolved to the real obs
+	// path by the model config when creating the job.
+	InputSourceModel      = "model"
+	InputSourceData       = "data"
+	InputSourceEvalSet    = "eval_set"
+	InputSourceCheckpoint = "checkpoint"
+
+	// OutputKeyDefault means the output key configured
+	// for the model will be used.
+	OutputKeyDefault = ""
+
+	// PostProcessingPackOutput packs the output dir to a zip file
+	// after the job completed successfully.
+	PostProcessingPackOutput = "pack_output"
+
+	// PostProcessingParseMetrics parses the metrics file in
+	// the output dir after the job completed successfully.
+	PostProcessingParseMetrics = "parse_metrics"
+
+	// PostProcessingCollectImages enumerates the generated images
+	// in the output dir and makes thumbnails for them.
+	PostProcessingCollectImages = "collect_images"
+)
+
+var taskKinds = map[string]*TaskKind{}
+
+func init() {
+	RegisterTaskKind(TaskFinetune, TaskKind{
+		Commands: map[string]string{
+			"wukong": "python train-lora.py",
+		},
+		Inputs: []TaskInput{
+			{Key: "model_path", Source: InputSourceModel},
+			{Key: "finetune_data_path", Source: InputSourceData},
+			{Key: "resume_ckpt_path", Source: InputSourceCheckpoint, Optional: true},
+		},
+		Outputs: []TaskOutput{{Key: OutputKeyDefault}},
+		PostProcessing: []string{
+			PostProcessingPackOutput, PostProcessingParseMetrics,
+		},
+	})
+
+	RegisterTaskKind(TaskInference, TaskKind{
+		Commands: map[string]string{
+			"wukong": "python txt2img-lora.py",
+		},
+		Inputs: []TaskInput{
+			{Key: "model_path", Source: InputSourceModel},
+			{Key: "finetune_data_path", Source: InputSourceData},
+			{Key: "lora_path", Source: InputSourceCheckpoint, Optional: true},
+		},
+		Outputs: []TaskOutput{{Key: OutputKeyDefault}},
+		PostProcessing: []string{
+			PostProcessingPackOutput, PostProcessingCollectImages,
+		},
+		AcceptPrompts: true,
+	})
+
+	RegisterTaskKind(TaskEvaluate, TaskKind{
+		Commands: map[string]string{
+			"wukong": "python eval-lora.py",
+		},
+		Inputs: []TaskInput{
+			{Key: "model_path", Source: InputSourceModel},
+			{Key: "lora_path", Source: InputSourceCheckpoint},
+			{Key: "eval_data_path", Source: InputSourceEvalSet},
+		},
+		Outputs:        []TaskOutput{{Key: OutputKeyDefault}},
+		PostProcessing: []string{PostProcessingParseMetrics},
+	})
+}
+
+// TaskKind defines how a kind of task runs.
+type TaskKind struct {
+	// Commands maps the model name to the command
+	// which runs the task of that model.
+	Commands map[string]string
+
+	Inputs         []TaskInput
+	Outputs        []TaskOutput
+	PostProcessing []string
+
+	// AcceptPrompts means the task accepts the
+	// structured prompts of inference.
+	AcceptPrompts bool
+}
+
+type TaskInput struct {
+	Key    string
+	Source string
+
+	// Optional means the input will be omitted
+	// if the source is not provided.
+	Optional bool
+}
+
+type TaskOutput struct {
+	Key string
+}
+
+func (k *TaskKind) Command(model string) string {
+	return k.Commands[model]
+}
+
+func (k *TaskKind) SupportModel(model string) bool {
+	_, ok := k.Commands[model]
+
+	return ok
+}
+
+func (k *TaskKind) HasInput(source string) bool {
+	for i := range k.Inputs {
+		if k.Inputs[i].Source == source {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NeedInput returns true if the source must be provided.
+func (k *TaskKind) NeedInput(source string) bool {
+	for i := range k.Inputs {
+		if v := &k.Inputs[i]; v.Source == source && !v.Optional {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (k *TaskKind) HasPostProcessing(p string) bool {
+	for _, v := range k.PostProcessing {
+		if v == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RegisterTaskKind registers a kind of task. It should be called
+// in the init function and will panic if the name is duplicate.
+func RegisterTaskKind(name string, k TaskKind) {
+	if name == "" {
+		panic("empty task kind name")
+	}
+
+	if _, ok := taskKinds[name]; ok {
+		panic("duplicate task kind: " + name)
+	}
+
+	taskKinds[name] = &k
+}
+
+// TaskType
+type TaskType interface {
+	TaskType() string
+	Kind() *TaskKind
+}
+
+func NewTaskType(v string) (TaskType, error) {
+	if _, ok := taskKinds[v]; !ok {
+		return nil, errors.New("unsupported task")
+	}
+
+	return taskType(v), nil
+}
+
+type taskType string
+
+func (t taskType) TaskType() string {
+	return string(t)
+}
+
+func (t taskType) Kind() *TaskKind {
+	return taskKinds[string(t)]
+}
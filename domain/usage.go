@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+const usageMonthLayout = "2006-01"
+
+// Usage is the resource consumed by a job of finetune. It is recorded
+// when the job is done, including the jobs which were retried.
+type Usage struct {
+	JobId      string
+	FinetuneId string
+	User       string
+	Model      string
+	Task       string
+	Status     string
+
+	Flavor    string
+	NodeCount int
+	// CardNum is the num of cards of each node.
+	CardNum int
+
+	// Duration is the run time of job in second.
+	Duration int
+
+	// FinishedAt is the unix time when the job is done.
+	FinishedAt int64
+}
+
+// Cards returns the num of cards used by the job. The node
+// count and card num are 1 if they were not recorded.
+func (u *Usage) Cards() int {
+	n, c := u.NodeCount, u.CardNum
+	if n <= 0 {
+		n = 1
+	}
+
+	if c <= 0 {
+		c = 1
+	}
+
+	return n * c
+}
+
+func (u *Usage) CardHours() float64 {
+	return float64(u.Cards()) * float64(u.Duration) / 3600
+}
+
+// Month returns the month in UTC when the job is done, such as 2023-05.
+func (u *Usage) Month() string {
+	return time.Unix(u.FinishedAt, 0).UTC().Format(usageMonthLayout)
+}
+
+// IsUsageMonth checks whether v is a month in the format of 2023-05.
+func IsUsageMonth(v string) bool {
+	_, err := time.Parse(usageMonthLayout, v)
+
+	return err == nil
+}
@@ -0,0 +1,40 @@
+package watch
+
+import "github.com/opensourceways/xihe-aicc-finetune/domain"
+
+// CallbackEvent is the status of finetune to report to the xihe server.
+// The events of a finetune are delivered in the order of Seq.
+type CallbackEvent struct {
+	Id         string
+	FinetuneId string
+	User       string
+	Model      string
+	Seq        int64
+
+	Status        string
+	Duration      int
+	LogPath       string
+	OutputZipPath string
+
+	// Progress is the training progress when the event happened.
+	Progress *domain.Progress
+
+	// Final means it is the last event of finetune.
+	Final bool
+
+	Attempts int
+	// NextAttemptAt is the unix time when to deliver it again.
+	NextAttemptAt int64
+	LastError     string
+
+	// Dead means it will not be delivered any more
+	// after failed for the max attempts.
+	Dead bool
+
+	// Superseded means a later event of the finetune has been delivered
+	// after it was dead, so it can't be replayed which would report
+	// the stale status.
+	Superseded bool
+
+	CreatedAt int64
+}
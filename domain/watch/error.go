@@ -0,0 +1,19 @@
+package watch
+
+import "errors"
+
+// errorExceedMaxWatchNum means there is no capacity to watch the
+// finetune now, so it can be applied again after some finetunes done.
+type errorExceedMaxWatchNum struct {
+	error
+}
+
+func NewErrorExceedMaxWatchNum() errorExceedMaxWatchNum {
+	return errorExceedMaxWatchNum{errors.New("exceed max watch num")}
+}
+
+func IsErrorExceedMaxWatchNum(err error) bool {
+	_, ok := err.(errorExceedMaxWatchNum)
+
+	return ok
+}
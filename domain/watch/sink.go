@@ -0,0 +1,31 @@
+package watch
+
+// FinetuneStatus is the status of finetune reported to the xihe server.
+type FinetuneStatus struct {
+	FinetuneId    string `json:"id"`
+	User          string `json:"user"`
+	Model         string `json:"model"`
+	Status        string `json:"status"`
+	Duration      int    `json:"duration"`
+	LogPath       string `json:"log_path"`
+	OutputZipPath string `json:"output_zip_path"`
+
+	Progress *ProgressStatus `json:"progress,omitempty"`
+}
+
+// ProgressStatus is the training progress of the running finetune.
+type ProgressStatus struct {
+	Step        int     `json:"step"`
+	TotalSteps  int     `json:"total_steps"`
+	Epoch       int     `json:"epoch,omitempty"`
+	TotalEpochs int     `json:"total_epochs,omitempty"`
+	Loss        float64 `json:"loss"`
+	ETA         int     `json:"eta"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+// StatusSink receives the status of finetunes from the watcher.
+type StatusSink interface {
+	Report(*FinetuneStatus) error
+	Close() error
+}
@@ -4,11 +4,61 @@ import "github.com/opensourceways/xihe-aicc-finetune/domain"
 
 type FinetuneInfo struct {
 	User       domain.Account
+	Model      domain.ModelName
+	Task       domain.TaskType
 	FinetuneId string
 
 	domain.JobInfo
 }
 
+// WatchState is the state of finetune being watched. It is saved
+// when the watcher exits and restored when the watcher starts.
+type WatchState struct {
+	FinetuneInfo
+
+	PriorityClass string
+
+	// PendingSince and QueuedSince are the unix time for
+	// resubmitting the pending job and the queue timeout.
+	PendingSince int64
+	QueuedSince  int64
+
+	Done        bool
+	Success     bool
+	LogDone     bool
+	OutputDone  bool
+	MetricsDone bool
+	ImagesDone  bool
+}
+
+// AdmissionRecord is the finetune admitted to watch by any replica.
+type AdmissionRecord struct {
+	PriorityClass string
+
+	// AdmittedAt is the unix time when it is admitted.
+	AdmittedAt int64
+}
+
+// DoneHandler is called after the finetune is done and reported.
+type DoneHandler func(finetuneId string)
+
+// Admission is the finetune applying for being watched.
+type Admission struct {
+	FinetuneId string
+	User       domain.Account
+	Task       domain.TaskType
+
+	// PriorityClass is the class requested. The class
+	// of user or task will be used if it is empty.
+	PriorityClass string
+}
+
 type WatchService interface {
-	ApplyWatch(f func(*FinetuneInfo) error) (err error)
+	ApplyWatch(a *Admission, f func(*FinetuneInfo) error) (err error)
+
+	// Available returns the num of finetunes which can be watched more.
+	Available() int
+
+	// Watching returns true if the finetune is being watched.
+	Watching(finetuneId string) bool
 }
@@ -1,18 +1,42 @@
 package aicc
 
 type Job struct {
-	Metadata JobMetadata `json:"metadata"`
-	Status   JobStatus   `json:"status"`
+	Metadata  JobMetadata     `json:"metadata"`
+	Status    JobStatus       `json:"status"`
+	Algorithm AlgorithmOption `json:"algorithm"`
+	Spec      SpecOption      `json:"spec"`
 }
 
 type JobMetadata struct {
-	Id string `json:"id"`
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	CreateTime int64  `json:"create_time"`
+}
+
+type JobSearchOption struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type JobSearchResp struct {
+	Total int   `json:"total"`
+	Count int   `json:"count"`
+	Items []Job `json:"items"`
 }
 
 type JobStatus struct {
-	Phase     string `json:"phase"`
-	Duration  int    `json:"duration"`
-	StartTime int    `json:"start_time"`
+	Phase          string       `json:"phase"`
+	SecondaryPhase string       `json:"secondary_phase"`
+	ReasonCode     string       `json:"reason_code"`
+	Duration       int          `json:"duration"`
+	StartTime      int          `json:"start_time"`
+	TaskStatuses   []TaskStatus `json:"task_statuses"`
+}
+
+type TaskStatus struct {
+	Task     string `json:"task"`
+	ExitCode int    `json:"exit_code"`
+	Message  string `json:"message"`
 }
 
 type GetResp struct {
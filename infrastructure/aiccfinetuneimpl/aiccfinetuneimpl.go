@@ -1,21 +1,20 @@
 package aiccfinetuneimpl
 
 import (
-	"strconv"
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/opensourceways/community-robot-lib/utils"
+
 	"github.com/opensourceways/xihe-aicc-finetune/config"
 	"github.com/opensourceways/xihe-aicc-finetune/domain"
 	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
-
 	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/aicc"
 )
 
 const (
 	obsDelimiter = "/"
-	modelPathKey = "model_path"
-	dataPathKey  = "finetune_data_path"
 )
 
 var statusMap = map[string]domain.TrainingStatus{
@@ -42,213 +41,87 @@ func NewAiccFinetune(cfg *config.Config) (aiccfinetune.AICCFinetune, error) {
 	}
 
 	return aiccFinetuneImpl{
-		cli:    cli,
-		config: cfg.Finetune,
-		helper: h,
+		cli:       cli,
+		config:    cfg.Finetune,
+		helper:    h,
+		scheduler: newPoolScheduler(),
 	}, nil
 }
 
 type aiccFinetuneImpl struct {
-	cli    aiccClient
-	config config.FinetuneConfig
+	cli       aiccClient
+	config    config.FinetuneConfig
+	scheduler *poolScheduler
 
 	*helper
 }
 
-func (impl aiccFinetuneImpl) genJobParameter(t *domain.AICCFinetune, opt *aicc.JobCreateOption) {
-	if n := len(t.Hyperparameters); n > 0 {
-		p := make([]aicc.ParameterOption, n)
-
-		for i, v := range t.Hyperparameters {
-			s := ""
-			if v.Value != nil {
-				s = v.Value.CustomizedValue()
-			}
-
-			p[i] = aicc.ParameterOption{
-				Name:  v.Key.CustomizedKey(),
-				Value: s,
-			}
-		}
-
-		opt.Algorithm.Parameters = p
+func (impl aiccFinetuneImpl) modelConfig(model string) (*config.ModelConfig, error) {
+	if model == "wukong" {
+		return &impl.config.WukongConfig, nil
 	}
 
-	if n := len(t.Env); n > 0 {
-		m := make(map[string]string)
-
-		for _, v := range t.Env {
-			s := ""
-			if v.Value != nil {
-				s = v.Value.CustomizedValue()
-			}
+	return nil, fmt.Errorf("unsupported model: %s", model)
+}
 
-			m[v.Key.CustomizedKey()] = s
-		}
+// jobSpec builds the spec of job which will be put on a pool except the
+// excluded one. The placement is not changed if it is only rendered.
+func (impl aiccFinetuneImpl) jobSpec(
+	t *domain.AICCFinetune, exclude string, dryRun bool,
+) (spec jobSpec, err error) {
+	cfg, err := impl.modelConfig(t.Model.ModelName())
+	if err != nil {
+		return
+	}
 
-		opt.Algorithm.Environments = m
+	pool, err := impl.scheduler.choose(cfg, exclude, dryRun)
+	if err != nil {
+		return
 	}
+
+	return newJobSpec(t, cfg, impl.config.Entitlement(t.User.Account()), pool)
 }
 
 func (impl aiccFinetuneImpl) Create(t *domain.AICCFinetune) (info domain.JobInfo, err error) {
-	var cfg *config.ModelConfig
-	if t.Model.ModelName() == "wukong" {
-		cfg = &impl.config.WukongConfig
-	}
+	return impl.create(t, "")
+}
 
-	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	logDir := cfg.LogDir + t.Task + obsDelimiter + t.User.Account() + obsDelimiter
-	outputDir := cfg.OutputDir + t.Task + obsDelimiter + t.User.Account() + obsDelimiter
-
-	outputs := []aicc.InputOutputOption{}
-	outputs = append(outputs, aicc.InputOutputOption{
-		Name: cfg.OutputKey,
-		Remote: aicc.RemoteOption{
-			OBS: aicc.OBSOption{
-				OBSURL: outputDir,
-			},
-		},
-	})
-
-	inputs := []aicc.InputOutputOption{}
-	inputs = append(inputs, aicc.InputOutputOption{
-		Name: modelPathKey,
-		Remote: aicc.RemoteOption{
-			OBS: aicc.OBSOption{
-				OBSURL: cfg.ModelDir,
-			},
-		},
-	})
-	inputs = append(inputs, aicc.InputOutputOption{
-		Name: dataPathKey,
-		Remote: aicc.RemoteOption{
-			OBS: aicc.OBSOption{
-				OBSURL: cfg.InputDir + t.Task + obsDelimiter + t.User.Account() + obsDelimiter,
-			},
-		},
-	})
-
-	opt := aicc.JobCreateOption{
-		Kind: "job",
-		Metadata: aicc.MetadataOption{
-			Name: t.Name.FinetuneName() + t.User.Account() + "-" + timestamp + "-" + t.Task,
-			Desc: t.Desc.FinetuneDesc(),
-		},
-		Algorithm: aicc.AlgorithmOption{
-			CodeDir:    cfg.CodeDir,
-			WorkingDir: cfg.WorkingDir,
-			Command:    t.DefaultCommand(),
-			Engine: aicc.EngineOption{
-				ImageURL: cfg.ImageURL,
-			},
-			Inputs:  inputs,
-			Outputs: outputs,
-		},
-		Spec: aicc.SpecOption{
-			Resource: aicc.ResourceOption{
-				FlavorId:  cfg.FlavorId,
-				PoolId:    cfg.PoolId,
-				PoolName:  cfg.PoolName,
-				NodeCount: 1,
-			},
-			LogExportPath: aicc.LogExportPathOption{
-				OBSURL: logDir,
-			},
-		},
-	}
+func (impl aiccFinetuneImpl) Resubmit(t *domain.AICCFinetune) (info domain.JobInfo, err error) {
+	return impl.create(t, t.Job.Pool)
+}
 
-	impl.genJobParameter(t, &opt)
+func (impl aiccFinetuneImpl) create(t *domain.AICCFinetune, exclude string) (info domain.JobInfo, err error) {
+	spec, err := impl.jobSpec(t, exclude, false)
+	if err != nil {
+		return
+	}
 
-	info.JobId, err = impl.cli.createJob(opt)
+	info.JobId, err = impl.cli.createJob(spec.toOption())
 
 	if err == nil {
-		p := ""
-		info.LogDir = strings.TrimPrefix(logDir, p)
-		info.OutputDir = strings.TrimPrefix(outputDir, p)
+		info.LogDir = spec.logDir
+		info.OutputDir = spec.outputDir
+		info.Pool = spec.poolId
+		info.CreatedAt = time.Now().Unix()
+		info.MaxQueueTime = spec.maxQueueTime
+		info.MaxRunTime = spec.maxRunTime
+		info.Flavor = spec.flavorName
+		info.NodeCount = spec.nodeCount
+		info.CardNum = spec.cardNum
+
+		impl.scheduler.submitted(info.JobId, spec.poolId)
 	}
 
 	return
 }
 
-func (impl aiccFinetuneImpl) CreateInference(t *domain.AICCFinetune) (info domain.JobInfo, err error) {
-	var cfg *config.ModelConfig
-	if t.Model.ModelName() == "wukong" {
-		cfg = &impl.config.WukongConfig
-	}
-
-	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	logDir := cfg.LogDir + t.Task + t.User.Account() + obsDelimiter
-	outputDir := cfg.OutputDir + t.Task + t.User.Account() + obsDelimiter
-
-	outputs := []aicc.InputOutputOption{}
-	outputs = append(outputs, aicc.InputOutputOption{
-		Name: cfg.OutputKey,
-		Remote: aicc.RemoteOption{
-			OBS: aicc.OBSOption{
-				OBSURL: outputDir,
-			},
-		},
-	})
-
-	inputs := []aicc.InputOutputOption{}
-	inputs = append(inputs, aicc.InputOutputOption{
-		Name: modelPathKey,
-		Remote: aicc.RemoteOption{
-			OBS: aicc.OBSOption{
-				OBSURL: cfg.ModelDir,
-			},
-		},
-	})
-
-	inputs = append(inputs, aicc.InputOutputOption{
-		Name: dataPathKey,
-		Remote: aicc.RemoteOption{
-			OBS: aicc.OBSOption{
-				OBSURL: cfg.InputDir + t.Task + t.User.Account() + obsDelimiter,
-			},
-		},
-	})
-
-	opt := aicc.JobCreateOption{
-		Kind: "job",
-		Metadata: aicc.MetadataOption{
-			Name: t.Name.FinetuneName() + t.User.Account() + "-" + timestamp + "-" + t.Task,
-			Desc: t.Desc.FinetuneDesc(),
-		},
-		Algorithm: aicc.AlgorithmOption{
-			CodeDir:    cfg.CodeDir,
-			WorkingDir: cfg.WorkingDir,
-			Command:    t.DefaultInferenceCommand(),
-			Engine: aicc.EngineOption{
-				ImageURL: cfg.ImageURL,
-			},
-			Inputs:  inputs,
-			Outputs: outputs,
-		},
-		Spec: aicc.SpecOption{
-			Resource: aicc.ResourceOption{
-				FlavorId:  cfg.FlavorId,
-				PoolId:    cfg.PoolId,
-				PoolName:  cfg.PoolName,
-				NodeCount: 1,
-			},
-			LogExportPath: aicc.LogExportPathOption{
-				OBSURL: logDir,
-			},
-		},
-	}
-
-	impl.genJobParameter(t, &opt)
-
-	info.JobId, err = impl.cli.createJob(opt)
-
-	if err == nil {
-		p := ""
-		info.LogDir = strings.TrimPrefix(logDir, p)
-		info.OutputDir = strings.TrimPrefix(outputDir, p)
+func (impl aiccFinetuneImpl) Render(t *domain.AICCFinetune) ([]byte, error) {
+	spec, err := impl.jobSpec(t, "", true)
+	if err != nil {
+		return nil, err
 	}
 
-	return
+	return utils.JsonMarshal(spec.toOption())
 }
 
 func (impl aiccFinetuneImpl) GetDetail(jobId string) (r domain.JobDetail, err error) {
@@ -265,26 +138,80 @@ func (impl aiccFinetuneImpl) GetDetail(jobId string) (r domain.JobDetail, err er
 
 	// convert millisecond to second
 	r.Duration = v.Status.Duration / 1000
+	r.StartedAt = int64(v.Status.StartTime) / 1000
+
+	if r.Status == domain.TrainingStatusFailed || r.Status == domain.TrainingStatusAbnormal {
+		r.Error, r.ErrorCategory = jobFailure(&v.Status)
+	}
+
+	if r.Status != domain.TrainingStatusPending && r.Status != domain.TrainingStatusCreating {
+		impl.scheduler.started(jobId)
+	}
 
 	return
 }
 func (impl aiccFinetuneImpl) Delete(jobId string) error {
 	err := impl.cli.deleteJob(jobId)
+	if err == nil {
+		impl.scheduler.started(jobId)
+	}
+
 	return err
 }
 
-func (impl aiccFinetuneImpl) GetLogFilePath(jobId string) (string, error) {
-	return impl.cli.getLogURL(jobId)
+func (impl aiccFinetuneImpl) GenOutput(outputDir string) (string, error) {
+	return impl.uploadFolder(outputDir)
 }
 
-func (impl aiccFinetuneImpl) GenOutput(jobId string) (string, error) {
-	return impl.uploadFolder(jobId)
+func (impl aiccFinetuneImpl) GetLogDownloadURL(jobId string) (string, error) {
+	return impl.cli.getLogURL(jobId)
 }
 
-func (impl aiccFinetuneImpl) GetLogDownloadURL(outputDir string) (string, error) {
-	return impl.cli.getLogURL(outputDir)
+// jobFailure explains why the job failed by the exit codes of
+// its tasks and the secondary phase of platform.
+func jobFailure(s *aicc.JobStatus) (msg, category string) {
+	items := []string{}
+
+	for i := range s.TaskStatuses {
+		t := &s.TaskStatuses[i]
+
+		c, m := domain.DiagnoseExitCode(t.ExitCode)
+		if c == "" {
+			continue
+		}
+
+		if category == "" {
+			category = c
+		}
+
+		item := t.Task + " " + m
+		if t.Message != "" {
+			item += ": " + t.Message
+		}
+
+		items = append(items, item)
+	}
+
+	if s.SecondaryPhase != "" || s.ReasonCode != "" {
+		if category == "" {
+			category = domain.FailurePlatform
+		}
+
+		items = append(items, fmt.Sprintf(
+			"secondary phase: %s, reason code: %s", s.SecondaryPhase, s.ReasonCode,
+		))
+	}
+
+	msg = strings.Join(items, "; ")
+
+	return
 }
 
 func (impl aiccFinetuneImpl) Terminate(jobId string) error {
-	return impl.cli.terminateJob(jobId)
+	err := impl.cli.terminateJob(jobId)
+	if err == nil {
+		impl.scheduler.started(jobId)
+	}
+
+	return err
 }
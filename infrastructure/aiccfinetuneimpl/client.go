@@ -93,6 +93,10 @@ func (cli *aiccClient) terminateURL(jobId string) string {
 	return cli.createURL() + "/" + jobId + "/actions"
 }
 
+func (cli *aiccClient) searchURL() string {
+	return cli.Endpoint + "/v2/e0412da2cb3b4ebfb70c117343b8992a/training-job-searches"
+}
+
 func (cli *aiccClient) logURL(jobId string) string {
 	return cli.jobURL(jobId) + "/tasks/worker-0/logs/url"
 }
@@ -256,3 +260,36 @@ func (cli *aiccClient) getLogURL(jobId string) (log string, err error) {
 
 	return
 }
+
+func (cli *aiccClient) searchJobs(offset, limit int) (r aicc.JobSearchResp, err error) {
+	payload, err := utils.JsonMarshal(aicc.JobSearchOption{
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cli.searchURL(), bytes.NewBuffer(payload))
+	if err != nil {
+		return
+	}
+
+	token, err := cli.token()
+	if err != nil {
+		return
+	}
+
+	resp, err := cli.forwardTo(req, token)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode == 200 {
+		err = ParseResponse(resp, &r)
+	} else {
+		err = errors.New(resp.Status)
+	}
+
+	return
+}
@@ -0,0 +1,171 @@
+package aiccfinetuneimpl
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
+)
+
+const thumbnailDir = "thumbnails/"
+
+var imageExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+}
+
+// CollectImages enumerates the images generated in the output dir
+// and uploads a thumbnail for each of them. The thumbnail is empty
+// if the image can't be decoded, so a bad image will not fail the others.
+func (s *helper) CollectImages(outputDir string) ([]domain.InferenceImage, error) {
+	if !strings.HasSuffix(outputDir, "/") {
+		outputDir += "/"
+	}
+
+	keys, err := s.listImages(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]domain.InferenceImage, len(keys))
+	for i, k := range keys {
+		t, err := s.genThumbnail(outputDir, k)
+		if err != nil && !aiccfinetune.IsErrorInvalidFile(err) {
+			return nil, err
+		}
+
+		r[i] = domain.InferenceImage{
+			Path:      k,
+			Thumbnail: t,
+		}
+	}
+
+	return r, nil
+}
+
+func (s *helper) listImages(outputDir string) ([]string, error) {
+	input := &obs.ListObjectsInput{}
+	input.Bucket = s.bucket
+	input.Prefix = outputDir
+
+	var r []string
+
+	for {
+		output, err := s.obsClient.ListObjects(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range output.Contents {
+			k := output.Contents[i].Key
+
+			if strings.HasPrefix(k, outputDir+thumbnailDir) {
+				continue
+			}
+
+			if imageExts[strings.ToLower(path.Ext(k))] {
+				r = append(r, k)
+			}
+		}
+
+		if !output.IsTruncated {
+			return r, nil
+		}
+
+		input.Marker = output.NextMarker
+	}
+}
+
+func (s *helper) genThumbnail(outputDir, key string) (string, error) {
+	input := &obs.GetObjectInput{}
+	input.Bucket = s.bucket
+	input.Key = key
+
+	output, err := s.obsClient.GetObject(input)
+	if err != nil {
+		return "", err
+	}
+
+	buf, err := encodeThumbnail(output.Body, s.suc.ThumbnailSize)
+	output.Body.Close()
+
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimPrefix(key, outputDir)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	t := outputDir + thumbnailDir + strings.ReplaceAll(name, "/", "_") + ".png"
+
+	put := &obs.PutObjectInput{}
+	put.Bucket = s.bucket
+	put.Key = t
+	put.Body = buf
+
+	if _, err := s.obsClient.PutObject(put); err != nil {
+		return "", err
+	}
+
+	return t, nil
+}
+
+// encodeThumbnail decodes the image and encodes the thumbnail of it in png.
+func encodeThumbnail(r io.Reader, max int) (*bytes.Buffer, error) {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, aiccfinetune.NewErrorInvalidFile(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, resizeImage(src, max)); err != nil {
+		return nil, aiccfinetune.NewErrorInvalidFile(err)
+	}
+
+	return buf, nil
+}
+
+// resizeImage scales the image down by nearest neighbour sampling
+// so that both the width and height are not bigger than max.
+func resizeImage(src image.Image, max int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if w <= max && h <= max {
+		return src
+	}
+
+	tw, th := max, max
+	if w > h {
+		th = h * max / w
+	} else {
+		tw = w * max / h
+	}
+
+	if tw == 0 {
+		tw = 1
+	}
+
+	if th == 0 {
+		th = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy := b.Min.Y + y*h/th
+
+		for x := 0; x < tw; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*w/tw, sy))
+		}
+	}
+
+	return dst
+}
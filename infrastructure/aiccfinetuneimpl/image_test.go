@@ -0,0 +1,43 @@
+package aiccfinetuneimpl
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
+)
+
+func TestEncodeThumbnail(t *testing.T) {
+	src := new(bytes.Buffer)
+	if err := png.Encode(src, image.NewRGBA(image.Rect(0, 0, 300, 100))); err != nil {
+		t.Fatalf("encode image failed, err:%s", err.Error())
+	}
+
+	buf, err := encodeThumbnail(src, 60)
+	if err != nil {
+		t.Fatalf("encode thumbnail failed, err:%s", err.Error())
+	}
+
+	v, err := png.Decode(buf)
+	if err != nil {
+		t.Fatalf("decode thumbnail failed, err:%s", err.Error())
+	}
+
+	if b := v.Bounds(); b.Dx() != 60 || b.Dy() != 20 {
+		t.Errorf("got thumbnail of %dx%d, want 60x20", b.Dx(), b.Dy())
+	}
+}
+
+func TestEncodeThumbnailOfBadImage(t *testing.T) {
+	_, err := encodeThumbnail(strings.NewReader("not an image"), 60)
+	if err == nil {
+		t.Fatal("expect error for the image failed to decode")
+	}
+
+	if !aiccfinetune.IsErrorInvalidFile(err) {
+		t.Errorf("expect invalid file error, got %s", err.Error())
+	}
+}
@@ -0,0 +1,127 @@
+package aiccfinetuneimpl
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/aicc"
+)
+
+const jobSearchLimit = 50
+
+func (impl aiccFinetuneImpl) ListJobs() (r []domain.RemoteJob, err error) {
+	for offset := 0; ; {
+		v, err1 := impl.cli.searchJobs(offset, jobSearchLimit)
+		if err1 != nil {
+			return nil, err1
+		}
+
+		for i := range v.Items {
+			job, ok := toRemoteJob(&v.Items[i])
+			if ok && impl.ownsLogDir(job.LogDir) {
+				impl.fillResource(&job, &v.Items[i])
+				r = append(r, job)
+			}
+		}
+
+		offset += len(v.Items)
+		if len(v.Items) == 0 || offset >= v.Total {
+			return
+		}
+	}
+}
+
+// ownsLogDir checks whether the log dir is under the log dir of a model
+// of this service. The jobs of other deployments which share the project
+// may be named by the same convention, but their logs are put elsewhere.
+func (impl aiccFinetuneImpl) ownsLogDir(logDir string) bool {
+	roots := []string{impl.config.WukongConfig.LogDir}
+
+	for _, root := range roots {
+		if root != "" && strings.HasPrefix(logDir, root) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fillResource fills the resource of job by the flavor of model, and
+// the time limits which are not returned by the platform are the ones
+// of model, so the adopted job is limited and accounted as the others.
+func (impl aiccFinetuneImpl) fillResource(r *domain.RemoteJob, job *aicc.Job) {
+	cfg := &impl.config.WukongConfig
+
+	r.MaxQueueTime = cfg.MaxQueueTime
+	r.MaxRunTime = cfg.MaxRunTime
+
+	if r.NodeCount = job.Spec.Resource.NodeCount; r.NodeCount <= 0 {
+		r.NodeCount = 1
+	}
+
+	for i := range cfg.Flavors {
+		if f := &cfg.Flavors[i]; f.Id == job.Spec.Resource.FlavorId {
+			r.Flavor = f.Name
+			r.CardNum = f.CardNum
+
+			break
+		}
+	}
+}
+
+// isJobName checks whether the name is in the format of
+// name+user-timestamp-task which is used when creating the job.
+func isJobName(name string) bool {
+	v := strings.Split(name, "-")
+
+	n := len(v)
+	if n < 3 {
+		return false
+	}
+
+	if _, err := domain.NewTaskType(v[n-1]); err != nil {
+		return false
+	}
+
+	_, err := strconv.ParseInt(v[n-2], 10, 64)
+
+	return err == nil
+}
+
+func toRemoteJob(job *aicc.Job) (r domain.RemoteJob, ok bool) {
+	if !isJobName(job.Metadata.Name) {
+		return
+	}
+
+	// the log dir is in the format of root/task/user/finetune id/
+	logDir := job.Spec.LogExportPath.OBSURL
+	v := strings.Split(strings.TrimSuffix(logDir, obsDelimiter), obsDelimiter)
+
+	r = domain.RemoteJob{
+		JobInfo: domain.JobInfo{
+			JobId:  job.Metadata.Id,
+			LogDir: logDir,
+			Pool:   job.Spec.Resource.PoolId,
+
+			// convert millisecond to second
+			CreatedAt: job.Metadata.CreateTime / 1000,
+		},
+		Name:       job.Metadata.Name,
+		FinetuneId: v[len(v)-1],
+	}
+
+	if outputs := job.Algorithm.Outputs; len(outputs) > 0 {
+		r.OutputDir = outputs[0].Remote.OBS.OBSURL
+	}
+
+	if status, b := statusMap[strings.ToLower(job.Status.Phase)]; b {
+		r.Status = status
+	} else {
+		r.Status = domain.TrainingStatusFailed
+	}
+
+	ok = true
+
+	return
+}
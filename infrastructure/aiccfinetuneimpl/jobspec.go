@@ -0,0 +1,394 @@
+package aiccfinetuneimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/xihe-aicc-finetune/config"
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
+	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/aicc"
+)
+
+// jobSpec describes the aicc job of a finetune declaratively.
+// It is built from the finetune, the model config and the task kind,
+// and all the ways of creating job should render it by toOption.
+type jobSpec struct {
+	name    string
+	desc    string
+	command string
+
+	codeDir    string
+	workingDir string
+	imageURL   string
+
+	flavorId   string
+	flavorName string
+	cardNum    int
+	poolId     string
+	poolName   string
+	nodeCount  int
+
+	maxQueueTime int
+	maxRunTime   int
+
+	logDir    string
+	outputDir string
+
+	inputs     []jobIO
+	outputs    []jobIO
+	parameters []domain.KeyValue
+	envs       []domain.KeyValue
+
+	// systemEnvs are set by the service and override the envs.
+	systemEnvs map[string]string
+
+	// systemParameters are the parameters set by the service, such
+	// as the structured prompts, which override the hyperparameters.
+	systemParameters []aicc.ParameterOption
+}
+
+// resumeParameter tells the job to resume from the checkpoint.
+const resumeParameter = "resume"
+
+type jobIO struct {
+	key     string
+	obsPath string
+}
+
+// jobDir returns the dir of user for the task. The log and output
+// will be put in the sub dir named by finetune id, so that the output
+// of a finetune can be used by others.
+func jobDir(root, task string, user domain.Account) string {
+	return root + task + obsDelimiter + user.Account() + obsDelimiter
+}
+
+// inputDir returns the dir of input data of user for the task. There is
+// no delimiter between the task and user for inference as before, so the
+// data which was uploaded for inference can still be found.
+func inputDir(root, task string, user domain.Account) string {
+	if task == domain.TaskInference {
+		return root + task + user.Account() + obsDelimiter
+	}
+
+	return jobDir(root, task, user)
+}
+
+func newJobSpec(
+	t *domain.AICCFinetune,
+	cfg *config.ModelConfig,
+	ent *config.EntitlementConfig,
+	pool *config.PoolConfig,
+) (spec jobSpec, err error) {
+	if t.Id == "" {
+		err = errors.New("missing finetune id")
+
+		return
+	}
+
+	flavor, nodeCount, err := chooseResource(&t.Resource, cfg, ent)
+	if err != nil {
+		return
+	}
+
+	maxQueueTime, maxRunTime, err := chooseLimits(&t.Limits, cfg)
+	if err != nil {
+		return
+	}
+
+	kind := t.Task.Kind()
+	task := t.Task.TaskType()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	spec = jobSpec{
+		name:       t.Name.FinetuneName() + t.User.Account() + "-" + timestamp + "-" + task,
+		command:    t.Command(),
+		codeDir:    cfg.CodeDir,
+		workingDir: cfg.WorkingDir,
+		imageURL:   cfg.ImageURL,
+		flavorId:   flavor.Id,
+		flavorName: flavor.Name,
+		cardNum:    flavor.CardNum,
+		poolId:     pool.Id,
+		poolName:   pool.Name,
+		nodeCount:  nodeCount,
+
+		maxQueueTime: maxQueueTime,
+		maxRunTime:   maxRunTime,
+
+		logDir:     jobDir(cfg.LogDir, task, t.User) + t.Id + obsDelimiter,
+		outputDir:  jobDir(cfg.OutputDir, task, t.User) + t.Id + obsDelimiter,
+		parameters: t.Hyperparameters,
+		envs:       t.Env,
+	}
+
+	if t.Desc != nil {
+		spec.desc = t.Desc.FinetuneDesc()
+	}
+
+	if nodeCount > 1 {
+		spec.systemEnvs = distributedEnvs(flavor.CardNum, nodeCount)
+	}
+
+	if t.Inference != nil {
+		if spec.systemParameters, err = toPromptParameters(t.Inference); err != nil {
+			return
+		}
+	}
+
+	if t.Resume {
+		spec.systemParameters = append(spec.systemParameters, aicc.ParameterOption{
+			Name: resumeParameter, Value: "true",
+		})
+	}
+
+	dataDir := inputDir(cfg.InputDir, task, t.User)
+
+	sources := map[string]string{
+		domain.InputSourceModel: cfg.ModelDir,
+		domain.InputSourceData:  dataDir,
+	}
+
+	if t.EvalSet != nil && t.EvalSet.Directory() != "" {
+		sources[domain.InputSourceEvalSet] = dataDir +
+			strings.Trim(t.EvalSet.Directory(), obsDelimiter) + obsDelimiter
+	}
+
+	if t.Checkpoint != "" {
+		sources[domain.InputSourceCheckpoint] = t.Checkpoint
+	}
+
+	spec.inputs = make([]jobIO, 0, len(kind.Inputs))
+	for _, v := range kind.Inputs {
+		p, ok := sources[v.Source]
+		if !ok {
+			if v.Optional {
+				continue
+			}
+
+			err = fmt.Errorf("missing input source: %s", v.Source)
+
+			return
+		}
+
+		spec.inputs = append(spec.inputs, jobIO{key: v.Key, obsPath: p})
+	}
+
+	spec.outputs = make([]jobIO, len(kind.Outputs))
+	for i, v := range kind.Outputs {
+		key := v.Key
+		if key == domain.OutputKeyDefault {
+			key = cfg.OutputKey
+		}
+
+		spec.outputs[i] = jobIO{key: key, obsPath: spec.outputDir}
+	}
+
+	return
+}
+
+// chooseResource checks the resource chosen by user is
+// allowed by the model and the entitlement of user.
+func chooseResource(
+	r *domain.JobResource,
+	cfg *config.ModelConfig,
+	ent *config.EntitlementConfig,
+) (*config.FlavorConfig, int, error) {
+	flavor, err := cfg.Flavor(r.Flavor)
+	if err != nil {
+		return nil, 0, aiccfinetune.NewErrorNotAllowed(err)
+	}
+
+	n := r.NodeCount
+	if n <= 0 {
+		n = 1
+	}
+
+	if n > cfg.MaxNodeCount {
+		return nil, 0, aiccfinetune.NewErrorNotAllowed(fmt.Errorf(
+			"the node count should not be bigger than %d", cfg.MaxNodeCount,
+		))
+	}
+
+	var entitled bool
+	if ent == nil {
+		entitled = flavor.Name == cfg.Flavors[0].Name && n == 1
+	} else {
+		entitled = ent.HasFlavor(flavor.Name) &&
+			(n == 1 || n <= ent.MaxNodeCount)
+	}
+
+	if !entitled {
+		return nil, 0, aiccfinetune.NewErrorNotAllowed(fmt.Errorf(
+			"the user is not entitled to use %d node(s) of flavor %s",
+			n, flavor.Name,
+		))
+	}
+
+	return flavor, n, nil
+}
+
+// chooseLimits returns the time limits of job which override the ones
+// of model. The overridden value can't exceed the one of model.
+func chooseLimits(v *domain.JobLimits, cfg *config.ModelConfig) (q, r int, err error) {
+	q, r = cfg.MaxQueueTime, cfg.MaxRunTime
+
+	if v.MaxQueueTime > q || v.MaxRunTime > r {
+		err = aiccfinetune.NewErrorNotAllowed(fmt.Errorf(
+			"the max queue time and max run time should not exceed %d and %d",
+			q, r,
+		))
+
+		return
+	}
+
+	if v.MaxQueueTime > 0 {
+		q = v.MaxQueueTime
+	}
+
+	if v.MaxRunTime > 0 {
+		r = v.MaxRunTime
+	}
+
+	return
+}
+
+// distributedEnvs returns the envs of distributed training.
+func distributedEnvs(cardNum, nodeCount int) map[string]string {
+	return map[string]string{
+		"NODE_NUM":    strconv.Itoa(nodeCount),
+		"DEVICE_NUM":  strconv.Itoa(cardNum),
+		"RANK_SIZE":   strconv.Itoa(cardNum * nodeCount),
+		"DISTRIBUTED": "true",
+	}
+}
+
+func (spec *jobSpec) toOption() aicc.JobCreateOption {
+	return aicc.JobCreateOption{
+		Kind: "job",
+		Metadata: aicc.MetadataOption{
+			Name: spec.name,
+			Desc: spec.desc,
+		},
+		Algorithm: aicc.AlgorithmOption{
+			CodeDir:    spec.codeDir,
+			WorkingDir: spec.workingDir,
+			Command:    spec.command,
+			Engine: aicc.EngineOption{
+				ImageURL: spec.imageURL,
+			},
+			Parameters:   spec.toParameters(),
+			Environments: spec.toEnvironments(),
+			Inputs:       toInputOutputOptions(spec.inputs),
+			Outputs:      toInputOutputOptions(spec.outputs),
+		},
+		Spec: aicc.SpecOption{
+			Resource: aicc.ResourceOption{
+				FlavorId:  spec.flavorId,
+				PoolId:    spec.poolId,
+				PoolName:  spec.poolName,
+				NodeCount: spec.nodeCount,
+			},
+			LogExportPath: aicc.LogExportPathOption{
+				OBSURL: spec.logDir,
+			},
+		},
+	}
+}
+
+func (spec *jobSpec) toParameters() []aicc.ParameterOption {
+	n := len(spec.parameters) + len(spec.systemParameters)
+	if n == 0 {
+		return nil
+	}
+
+	overridden := make(map[string]bool, len(spec.systemParameters))
+	for i := range spec.systemParameters {
+		overridden[spec.systemParameters[i].Name] = true
+	}
+
+	p := make([]aicc.ParameterOption, 0, n)
+	for _, v := range spec.parameters {
+		if k := v.Key.CustomizedKey(); !overridden[k] {
+			p = append(p, aicc.ParameterOption{
+				Name:  k,
+				Value: customizedValue(v.Value),
+			})
+		}
+	}
+
+	return append(p, spec.systemParameters...)
+}
+
+func toPromptParameters(opt *domain.InferenceOption) ([]aicc.ParameterOption, error) {
+	prompts, err := json.Marshal(opt.Prompts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := []aicc.ParameterOption{
+		{Name: "prompt", Value: string(prompts)},
+		{Name: "seed", Value: strconv.Itoa(opt.Seed)},
+		{Name: "n_samples", Value: strconv.Itoa(opt.SampleCount)},
+		{Name: "width", Value: strconv.Itoa(opt.Width)},
+		{Name: "height", Value: strconv.Itoa(opt.Height)},
+	}
+
+	if len(opt.NegativePrompts) > 0 {
+		v, err := json.Marshal(opt.NegativePrompts)
+		if err != nil {
+			return nil, err
+		}
+
+		p = append(p, aicc.ParameterOption{
+			Name: "negative_prompt", Value: string(v),
+		})
+	}
+
+	return p, nil
+}
+
+func (spec *jobSpec) toEnvironments() map[string]string {
+	if len(spec.envs) == 0 && len(spec.systemEnvs) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string)
+	for _, v := range spec.envs {
+		m[v.Key.CustomizedKey()] = customizedValue(v.Value)
+	}
+
+	for k, v := range spec.systemEnvs {
+		m[k] = v
+	}
+
+	return m
+}
+
+func toInputOutputOptions(v []jobIO) []aicc.InputOutputOption {
+	r := make([]aicc.InputOutputOption, len(v))
+	for i := range v {
+		r[i] = aicc.InputOutputOption{
+			Name: v[i].key,
+			Remote: aicc.RemoteOption{
+				OBS: aicc.OBSOption{
+					OBSURL: v[i].obsPath,
+				},
+			},
+		}
+	}
+
+	return r
+}
+
+func customizedValue(v domain.CustomizedValue) string {
+	if v == nil {
+		return ""
+	}
+
+	return v.CustomizedValue()
+}
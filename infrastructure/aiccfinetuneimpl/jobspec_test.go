@@ -0,0 +1,29 @@
+package aiccfinetuneimpl
+
+import (
+	"testing"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+)
+
+func TestInputDir(t *testing.T) {
+	user, err := domain.NewAccount("alice")
+	if err != nil {
+		t.Fatalf("new account failed, err:%s", err.Error())
+	}
+
+	cases := []struct {
+		task string
+		want string
+	}{
+		{domain.TaskFinetune, "input/finetune/alice/"},
+		{domain.TaskEvaluate, "input/evaluate/alice/"},
+		{domain.TaskInference, "input/inferencealice/"},
+	}
+
+	for _, c := range cases {
+		if v := inputDir("input/", c.task, user); v != c.want {
+			t.Errorf("task %s: got %s, want %s", c.task, v, c.want)
+		}
+	}
+}
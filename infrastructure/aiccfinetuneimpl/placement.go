@@ -0,0 +1,139 @@
+package aiccfinetuneimpl
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/opensourceways/xihe-aicc-finetune/config"
+)
+
+// pendingExpiry is the time after which the job is regarded as started.
+// The job submitted by this process may be watched by another replica,
+// so this process will never know when it started.
+const pendingExpiry = time.Hour
+
+func newPoolScheduler() *poolScheduler {
+	return &poolScheduler{
+		pending: make(map[string]pendingJob),
+		current: make(map[string]int),
+	}
+}
+
+// poolScheduler chooses the pool for job by the placement of model.
+// It only knows the pending jobs submitted by this process.
+type poolScheduler struct {
+	lock sync.Mutex
+
+	// pending maps the id of job not started to its pool.
+	pending map[string]pendingJob
+
+	// current is the current weight of pool for round-robin.
+	current map[string]int
+}
+
+// choose returns a pool of model except the excluded one. The state of
+// placement is not changed if dryRun is true, such as rendering the job.
+func (s *poolScheduler) choose(
+	cfg *config.ModelConfig, exclude string, dryRun bool,
+) (*config.PoolConfig, error) {
+	pools := make([]*config.PoolConfig, 0, len(cfg.Pools))
+	for i := range cfg.Pools {
+		if cfg.Pools[i].Id != exclude {
+			pools = append(pools, &cfg.Pools[i])
+		}
+	}
+
+	if len(pools) == 0 {
+		return nil, errors.New("no pool available")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch cfg.Placement {
+	case config.PlacementLeastPending:
+		return s.leastPending(pools), nil
+
+	case config.PlacementRoundRobin:
+		return s.roundRobin(pools, dryRun), nil
+
+	default:
+		return pools[0], nil
+	}
+}
+
+type pendingJob struct {
+	pool        string
+	submittedAt time.Time
+}
+
+func (s *poolScheduler) leastPending(pools []*config.PoolConfig) *config.PoolConfig {
+	s.expire()
+
+	num := make(map[string]int, len(pools))
+	for _, v := range s.pending {
+		num[v.pool]++
+	}
+
+	r := pools[0]
+	for _, p := range pools[1:] {
+		// compare num[p]/p.Weight with num[r]/r.Weight
+		if num[p.Id]*r.Weight < num[r.Id]*p.Weight {
+			r = p
+		}
+	}
+
+	return r
+}
+
+// roundRobin is the smooth weighted round-robin.
+func (s *poolScheduler) roundRobin(pools []*config.PoolConfig, dryRun bool) *config.PoolConfig {
+	total := 0
+	r := pools[0]
+	current := make(map[string]int, len(pools))
+
+	for _, p := range pools {
+		current[p.Id] = s.current[p.Id] + p.Weight
+		total += p.Weight
+
+		if current[p.Id] > current[r.Id] {
+			r = p
+		}
+	}
+
+	if dryRun {
+		return r
+	}
+
+	current[r.Id] -= total
+
+	for k, v := range current {
+		s.current[k] = v
+	}
+
+	return r
+}
+
+func (s *poolScheduler) submitted(jobId, poolId string) {
+	s.lock.Lock()
+	s.expire()
+	s.pending[jobId] = pendingJob{pool: poolId, submittedAt: time.Now()}
+	s.lock.Unlock()
+}
+
+// expire removes the jobs pending too long. It should be called under lock.
+func (s *poolScheduler) expire() {
+	for k, v := range s.pending {
+		if time.Since(v.submittedAt) > pendingExpiry {
+			delete(s.pending, k)
+		}
+	}
+}
+
+// started should be called when the job is no longer pending.
+func (s *poolScheduler) started(jobId string) {
+	s.lock.Lock()
+	delete(s.pending, jobId)
+	s.lock.Unlock()
+}
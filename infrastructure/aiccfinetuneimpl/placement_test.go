@@ -0,0 +1,48 @@
+package aiccfinetuneimpl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opensourceways/xihe-aicc-finetune/config"
+)
+
+func TestLeastPendingExpire(t *testing.T) {
+	cfg := &config.ModelConfig{
+		Placement: config.PlacementLeastPending,
+		Pools:     []config.PoolConfig{{Id: "a", Weight: 1}, {Id: "b", Weight: 1}},
+	}
+
+	s := newPoolScheduler()
+	s.submitted("1", "a")
+	s.submitted("2", "a")
+	s.submitted("3", "b")
+
+	choose := func() string {
+		p, err := s.choose(cfg, "", false)
+		if err != nil {
+			t.Fatalf("choose pool failed, err:%s", err.Error())
+		}
+
+		return p.Id
+	}
+
+	if v := choose(); v != "b" {
+		t.Errorf("got pool %s, want b which has less pending jobs", v)
+	}
+
+	// the jobs on pool a were handed off and never known to start.
+	for _, id := range []string{"1", "2"} {
+		v := s.pending[id]
+		v.submittedAt = time.Now().Add(-pendingExpiry - time.Minute)
+		s.pending[id] = v
+	}
+
+	if v := choose(); v != "a" {
+		t.Errorf("got pool %s, want a whose pending jobs expired", v)
+	}
+
+	if n := len(s.pending); n != 1 {
+		t.Errorf("got %d pending jobs, want 1", n)
+	}
+}
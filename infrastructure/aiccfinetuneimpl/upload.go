@@ -1,14 +1,29 @@
 package aiccfinetuneimpl
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 	libutils "github.com/opensourceways/community-robot-lib/utils"
 	"github.com/opensourceways/xihe-aicc-finetune/config"
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
+)
+
+const (
+	metricsFile   = "metrics.json"
+	progressFile  = "progress.json"
+	checkpointExt = ".ckpt"
+
+	// logTailSize is the size of the end of log which
+	// is scanned to find why the job failed.
+	logTailSize = 64 << 10
 )
 
 func newHelper(cfg *config.Config) (*helper, error) {
@@ -69,7 +84,234 @@ func (s *helper) GetLogFilePath(logDir string) (p string, err error) {
 	return
 }
 
+// GetLogTail reads the end of the log file in the log dir. The log
+// file is found by listing the dir on obs rather than by the log url
+// of job, because only the obs key can be read by range.
+func (s *helper) GetLogTail(logDir string) (string, error) {
+	logPath, err := s.GetLogFilePath(logDir)
+	if err != nil || logPath == "" {
+		return "", err
+	}
+
+	meta := &obs.GetObjectMetadataInput{}
+	meta.Bucket = s.bucket
+	meta.Key = logPath
+
+	v, err := s.obsClient.GetObjectMetadata(meta)
+	if err != nil {
+		return "", err
+	}
+
+	input := &obs.GetObjectInput{}
+	input.Bucket = s.bucket
+	input.Key = logPath
+
+	if v.ContentLength > logTailSize {
+		input.RangeStart = v.ContentLength - logTailSize
+		input.RangeEnd = v.ContentLength - 1
+	}
+
+	output, err := s.obsClient.GetObject(input)
+	if err != nil {
+		return "", err
+	}
+
+	defer output.Body.Close()
+
+	b, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// GetMetrics parses the metrics file in the output dir.
+// Only the numeric values of the file will be kept.
+func (s *helper) GetMetrics(outputDir string) (domain.Metrics, error) {
+	key, err := s.findFile(outputDir, metricsFile)
+	if err != nil || key == "" {
+		return nil, err
+	}
+
+	b, err := s.readObject(key)
+	if err != nil {
+		return nil, err
+	}
+
+	v := map[string]interface{}{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, aiccfinetune.NewErrorInvalidFile(
+			fmt.Errorf("invalid metrics file %s, err:%s", key, err.Error()),
+		)
+	}
+
+	r := domain.Metrics{}
+	for k, item := range v {
+		if f, ok := item.(float64); ok {
+			r[k] = f
+		}
+	}
+
+	return r, nil
+}
+
+// GetProgress reads the progress file in the output dir which is rewritten
+// by the job during training. It parses the log if the file is not found.
+func (s *helper) GetProgress(outputDir, logDir string) (domain.Progress, error) {
+	key, err := s.findFile(outputDir, progressFile)
+	if err != nil {
+		return domain.Progress{}, err
+	}
+
+	if key == "" {
+		return s.getProgressFromLog(logDir)
+	}
+
+	b, err := s.readObject(key)
+	if err != nil {
+		return domain.Progress{}, err
+	}
+
+	var do progressFileDO
+	if err := json.Unmarshal(b, &do); err != nil {
+		return domain.Progress{}, fmt.Errorf(
+			"invalid progress file %s, err:%s", key, err.Error(),
+		)
+	}
+
+	return do.toProgress(), nil
+}
+
+func (s *helper) getProgressFromLog(logDir string) (domain.Progress, error) {
+	tail, err := s.GetLogTail(logDir)
+	if err != nil {
+		return domain.Progress{}, err
+	}
+
+	p, _ := domain.ParseProgressLog(tail)
+
+	return p, nil
+}
+
+func (s *helper) readObject(key string) ([]byte, error) {
+	input := &obs.GetObjectInput{}
+	input.Bucket = s.bucket
+	input.Key = key
+
+	output, err := s.obsClient.GetObject(input)
+	if err != nil {
+		return nil, err
+	}
+
+	defer output.Body.Close()
+
+	return ioutil.ReadAll(output.Body)
+}
+
+type progressFileDO struct {
+	Step        int     `json:"step"`
+	TotalSteps  int     `json:"total_steps"`
+	Epoch       int     `json:"epoch"`
+	TotalEpochs int     `json:"total_epochs"`
+	Loss        float64 `json:"loss"`
+	ETA         int     `json:"eta"`
+}
+
+func (do *progressFileDO) toProgress() domain.Progress {
+	return domain.Progress{
+		Step:        do.Step,
+		TotalSteps:  do.TotalSteps,
+		Epoch:       do.Epoch,
+		TotalEpochs: do.TotalEpochs,
+		Loss:        do.Loss,
+		ETA:         do.ETA,
+	}
+}
+
+// FindLatestCheckpoint returns the latest modified checkpoint
+// file in the output dir.
+func (s *helper) FindLatestCheckpoint(outputDir string) (string, error) {
+	if !strings.HasSuffix(outputDir, "/") {
+		outputDir += "/"
+	}
+
+	input := &obs.ListObjectsInput{}
+	input.Bucket = s.bucket
+	input.Prefix = outputDir
+
+	var latest *obs.Content
+
+	for {
+		output, err := s.obsClient.ListObjects(input)
+		if err != nil {
+			return "", err
+		}
+
+		for i := range output.Contents {
+			item := &output.Contents[i]
+
+			if path.Ext(item.Key) != checkpointExt {
+				continue
+			}
+
+			if latest == nil || item.LastModified.After(latest.LastModified) {
+				v := *item
+				latest = &v
+			}
+		}
+
+		if !output.IsTruncated {
+			break
+		}
+
+		input.Marker = output.NextMarker
+	}
+
+	if latest == nil {
+		return "", nil
+	}
+
+	return latest.Key, nil
+}
+
+// findFile returns the key of the first file whose name is
+// the specified one under the dir.
+func (s *helper) findFile(dir, name string) (string, error) {
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+
+	input := &obs.ListObjectsInput{}
+	input.Bucket = s.bucket
+	input.Prefix = dir
+
+	for {
+		output, err := s.obsClient.ListObjects(input)
+		if err != nil {
+			return "", err
+		}
+
+		for i := range output.Contents {
+			if k := output.Contents[i].Key; path.Base(k) == name {
+				return k, nil
+			}
+		}
+
+		if !output.IsTruncated {
+			return "", nil
+		}
+
+		input.Marker = output.NextMarker
+	}
+}
+
 func (s *helper) GenFileDownloadURL(p string) (string, error) {
+	// the url signed without key can list the bucket.
+	if p == "" {
+		return "", errors.New("empty obs path")
+	}
+
 	input := &obs.CreateSignedUrlInput{}
 	input.Method = obs.HttpMethodGet
 	input.Bucket = s.bucket
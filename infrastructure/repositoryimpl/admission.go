@@ -0,0 +1,117 @@
+package repositoryimpl
+
+import (
+	"path/filepath"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+// ledgerId is the id of the only document of ledger.
+const ledgerId = "ledger"
+
+func NewAdmissionLedgerRepository(cfg *Config) (repository.AdmissionLedger, error) {
+	s, err := newFileStore(filepath.Join(cfg.Dir, "admission"))
+	if err != nil {
+		return nil, err
+	}
+
+	return admissionLedgerImpl{s}, nil
+}
+
+// admissionLedgerImpl stores all the records in one document which
+// is read and written under the lock file, so the replicas admit
+// the finetunes one by one.
+type admissionLedgerImpl struct {
+	store *fileStore
+}
+
+type admissionLedgerDO struct {
+	Admitted map[string]admissionRecordDO `json:"admitted"`
+}
+
+type admissionRecordDO struct {
+	PriorityClass string `json:"priority_class,omitempty"`
+	AdmittedAt    int64  `json:"admitted_at"`
+}
+
+func (impl admissionLedgerImpl) read(do *admissionLedgerDO) error {
+	if err := impl.store.read(ledgerId, do); err != nil {
+		if !repository.IsErrorResourceNotExists(err) {
+			return err
+		}
+	}
+
+	if do.Admitted == nil {
+		do.Admitted = map[string]admissionRecordDO{}
+	}
+
+	return nil
+}
+
+func (impl admissionLedgerImpl) Admit(
+	finetuneId string, r *watch.AdmissionRecord,
+	admit func(map[string]watch.AdmissionRecord) bool,
+) (b bool, err error) {
+	err = impl.store.exclusive(ledgerId, func() error {
+		do := new(admissionLedgerDO)
+		if err := impl.read(do); err != nil {
+			return err
+		}
+
+		if _, ok := do.Admitted[finetuneId]; ok {
+			b = true
+
+			return nil
+		}
+
+		if b = admit(do.toRecords()); !b {
+			return nil
+		}
+
+		do.Admitted[finetuneId] = admissionRecordDO(*r)
+
+		return impl.store.write(ledgerId, do)
+	})
+
+	return
+}
+
+func (impl admissionLedgerImpl) Release(finetuneId string) error {
+	return impl.store.exclusive(ledgerId, func() error {
+		do := new(admissionLedgerDO)
+		if err := impl.read(do); err != nil {
+			return err
+		}
+
+		if _, ok := do.Admitted[finetuneId]; !ok {
+			return nil
+		}
+
+		delete(do.Admitted, finetuneId)
+
+		return impl.store.write(ledgerId, do)
+	})
+}
+
+func (impl admissionLedgerImpl) FindAll() (map[string]watch.AdmissionRecord, error) {
+	do := new(admissionLedgerDO)
+	if err := impl.store.get(ledgerId, do); err != nil {
+		if repository.IsErrorResourceNotExists(err) {
+			return map[string]watch.AdmissionRecord{}, nil
+		}
+
+		return nil, err
+	}
+
+	return do.toRecords(), nil
+}
+
+func (do *admissionLedgerDO) toRecords() map[string]watch.AdmissionRecord {
+	r := make(map[string]watch.AdmissionRecord, len(do.Admitted))
+	for k, v := range do.Admitted {
+		r[k] = watch.AdmissionRecord(v)
+	}
+
+	return r
+}
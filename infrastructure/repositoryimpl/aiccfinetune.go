@@ -0,0 +1,583 @@
+package repositoryimpl
+
+import (
+	"path/filepath"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+func NewAICCFinetuneRepository(cfg *Config) (repository.AICCFinetune, error) {
+	s, err := newFileStore(filepath.Join(cfg.Dir, "finetune"))
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := newFileStore(filepath.Join(cfg.Dir, "scheduled"))
+	if err != nil {
+		return nil, err
+	}
+
+	return finetuneRepoImpl{store: s, scheduled: scheduled}, nil
+}
+
+type finetuneRepoImpl struct {
+	store *fileStore
+
+	// scheduled is the index of finetunes which are not submitted.
+	scheduled *fileStore
+}
+
+func (impl finetuneRepoImpl) Save(t *domain.AICCFinetune) error {
+	do := new(finetuneDO)
+	toFinetuneDO(t, do)
+
+	return impl.store.save(t.Id, do)
+}
+
+func (impl finetuneRepoImpl) Get(finetuneId string) (r domain.AICCFinetune, err error) {
+	do := new(finetuneDO)
+	if err = impl.store.get(finetuneId, do); err != nil {
+		return
+	}
+
+	err = do.toFinetune(&r)
+
+	return
+}
+
+func (impl finetuneRepoImpl) FindAll() ([]domain.AICCFinetune, error) {
+	ids, err := impl.store.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]domain.AICCFinetune, 0, len(ids))
+	for _, id := range ids {
+		v, err := impl.Get(id)
+		if err != nil {
+			if repository.IsErrorResourceNotExists(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		r = append(r, v)
+	}
+
+	return r, nil
+}
+
+func (impl finetuneRepoImpl) UpdateDetail(finetuneId string, detail *domain.JobDetail) error {
+	do := new(finetuneDO)
+
+	return impl.store.update(finetuneId, do, func() error {
+		toJobDetailDO(detail, &do.JobDetail)
+
+		return nil
+	})
+}
+
+func (impl finetuneRepoImpl) UpdateJob(finetuneId string, job *domain.JobInfo) error {
+	do := new(finetuneDO)
+
+	return impl.store.update(finetuneId, do, func() error {
+		toJobInfoDO(job, &do.Job)
+
+		return nil
+	})
+}
+
+func (impl finetuneRepoImpl) AddChild(finetuneId, childId string) error {
+	do := new(finetuneDO)
+
+	return impl.store.update(finetuneId, do, func() error {
+		for _, v := range do.Children {
+			if v == childId {
+				return nil
+			}
+		}
+
+		do.Children = append(do.Children, childId)
+
+		return nil
+	})
+}
+
+func (impl finetuneRepoImpl) AddScheduled(finetuneId string, submitAt int64) error {
+	return impl.scheduled.save(finetuneId, &scheduledDO{SubmitAt: submitAt})
+}
+
+func (impl finetuneRepoImpl) FindScheduled(before int64) ([]string, error) {
+	ids, err := impl.scheduled.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]string, 0, len(ids))
+	for _, id := range ids {
+		do := new(scheduledDO)
+		if err := impl.scheduled.get(id, do); err != nil {
+			if repository.IsErrorResourceNotExists(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if do.SubmitAt <= before {
+			r = append(r, id)
+		}
+	}
+
+	return r, nil
+}
+
+func (impl finetuneRepoImpl) RemoveScheduled(finetuneId string) error {
+	return impl.scheduled.remove(finetuneId)
+}
+
+type scheduledDO struct {
+	SubmitAt int64 `json:"submit_at"`
+}
+
+type finetuneDO struct {
+	Id         string   `json:"id"`
+	User       string   `json:"user"`
+	Model      string   `json:"model"`
+	Task       string   `json:"task"`
+	Parent     string   `json:"parent,omitempty"`
+	Checkpoint string   `json:"checkpoint,omitempty"`
+	Resume     bool     `json:"resume,omitempty"`
+	Children   []string `json:"children,omitempty"`
+	Cron       string   `json:"cron,omitempty"`
+	SubmitAt   int64    `json:"submit_at,omitempty"`
+	Pipeline   string   `json:"pipeline,omitempty"`
+
+	configDO
+
+	Job       jobInfoDO   `json:"job"`
+	JobDetail jobDetailDO `json:"job_detail"`
+}
+
+type configDO struct {
+	Name            string       `json:"name"`
+	Desc            string       `json:"desc"`
+	Hyperparameters []keyValueDO `json:"hyperparameters,omitempty"`
+	Env             []keyValueDO `json:"env,omitempty"`
+	EvalSet         string       `json:"eval_set,omitempty"`
+	Inference       *inferenceDO `json:"inference,omitempty"`
+	Flavor          string       `json:"flavor,omitempty"`
+	NodeCount       int          `json:"node_count"`
+	MaxQueueTime    int          `json:"max_queue_time,omitempty"`
+	MaxRunTime      int          `json:"max_run_time,omitempty"`
+	Retry           *retryDO     `json:"retry,omitempty"`
+	PriorityClass   string       `json:"priority_class,omitempty"`
+}
+
+type inferenceDO struct {
+	Prompts         []string `json:"prompts"`
+	NegativePrompts []string `json:"negative_prompts,omitempty"`
+	Seed            int      `json:"seed"`
+	SampleCount     int      `json:"sample_count"`
+	Width           int      `json:"width"`
+	Height          int      `json:"height"`
+}
+
+type retryDO struct {
+	MaxRetries int      `json:"max_retries"`
+	Statuses   []string `json:"statuses"`
+}
+
+type attemptDO struct {
+	JobId     string `json:"job_id"`
+	Pool      string `json:"pool,omitempty"`
+	Status    string `json:"status"`
+	Duration  int    `json:"duration"`
+	CreatedAt int64  `json:"created_at"`
+	StartedAt int64  `json:"started_at,omitempty"`
+}
+
+type imageDO struct {
+	Path      string `json:"path"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+type progressDO struct {
+	Step        int     `json:"step"`
+	TotalSteps  int     `json:"total_steps"`
+	Epoch       int     `json:"epoch,omitempty"`
+	TotalEpochs int     `json:"total_epochs,omitempty"`
+	Loss        float64 `json:"loss"`
+	ETA         int     `json:"eta"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+func toProgressDO(p *domain.Progress) *progressDO {
+	if p == nil || p.IsEmpty() {
+		return nil
+	}
+
+	v := progressDO(*p)
+
+	return &v
+}
+
+func (do *progressDO) toProgress() (p domain.Progress) {
+	if do != nil {
+		p = domain.Progress(*do)
+	}
+
+	return
+}
+
+type keyValueDO struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type jobInfoDO struct {
+	Endpoint  string `json:"endpoint"`
+	JobId     string `json:"job_id"`
+	LogDir    string `json:"log_dir"`
+	OutputDir string `json:"output_dir"`
+	Pool      string `json:"pool,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+
+	MaxQueueTime int `json:"max_queue_time"`
+	MaxRunTime   int `json:"max_run_time"`
+
+	Flavor    string `json:"flavor,omitempty"`
+	NodeCount int    `json:"node_count,omitempty"`
+	CardNum   int    `json:"card_num,omitempty"`
+}
+
+type jobDetailDO struct {
+	Status     string             `json:"status"`
+	Error      string             `json:"error,omitempty"`
+	Category   string             `json:"error_category,omitempty"`
+	Reason     string             `json:"termination_reason,omitempty"`
+	LogPath    string             `json:"log_path,omitempty"`
+	OutputPath string             `json:"output_path,omitempty"`
+	Duration   int                `json:"duration"`
+	StartedAt  int64              `json:"started_at,omitempty"`
+	Metrics    map[string]float64 `json:"metrics,omitempty"`
+	Images     []imageDO          `json:"images,omitempty"`
+	Attempts   []attemptDO        `json:"attempts,omitempty"`
+	Progress   *progressDO        `json:"progress,omitempty"`
+	History    []progressDO       `json:"progress_history,omitempty"`
+}
+
+func toFinetuneDO(t *domain.AICCFinetune, do *finetuneDO) {
+	*do = finetuneDO{
+		Id:         t.Id,
+		User:       t.User.Account(),
+		Model:      t.Model.ModelName(),
+		Task:       t.Task.TaskType(),
+		Parent:     t.Parent,
+		Checkpoint: t.Checkpoint,
+		Resume:     t.Resume,
+		Children:   t.Children,
+		Cron:       t.Schedule.Cron,
+		SubmitAt:   t.Schedule.SubmitAt,
+		Pipeline:   t.Pipeline,
+	}
+
+	toConfigDO(&t.AICCFinetuneConfig, &do.configDO)
+	toJobInfoDO(&t.Job, &do.Job)
+	toJobDetailDO(&t.JobDetail, &do.JobDetail)
+}
+
+func toConfigDO(t *domain.AICCFinetuneConfig, do *configDO) {
+	*do = configDO{
+		Name:            t.Name.FinetuneName(),
+		Hyperparameters: toKeyValueDOs(t.Hyperparameters),
+		Env:             toKeyValueDOs(t.Env),
+		Flavor:          t.Resource.Flavor,
+		NodeCount:       t.Resource.NodeCount,
+		MaxQueueTime:    t.Limits.MaxQueueTime,
+		MaxRunTime:      t.Limits.MaxRunTime,
+		PriorityClass:   t.PriorityClass,
+	}
+
+	if t.Desc != nil {
+		do.Desc = t.Desc.FinetuneDesc()
+	}
+
+	if t.EvalSet != nil {
+		do.EvalSet = t.EvalSet.Directory()
+	}
+
+	if v := t.Retry; v != nil {
+		do.Retry = &retryDO{
+			MaxRetries: v.MaxRetries,
+			Statuses:   make([]string, len(v.Statuses)),
+		}
+
+		for i := range v.Statuses {
+			do.Retry.Statuses[i] = v.Statuses[i].TrainingStatus()
+		}
+	}
+
+	if v := t.Inference; v != nil {
+		do.Inference = &inferenceDO{
+			Prompts:         v.Prompts,
+			NegativePrompts: v.NegativePrompts,
+			Seed:            v.Seed,
+			SampleCount:     v.SampleCount,
+			Width:           v.Width,
+			Height:          v.Height,
+		}
+	}
+}
+
+func toJobInfoDO(job *domain.JobInfo, do *jobInfoDO) {
+	*do = jobInfoDO{
+		Endpoint:  job.Endpoint,
+		JobId:     job.JobId,
+		LogDir:    job.LogDir,
+		OutputDir: job.OutputDir,
+		Pool:      job.Pool,
+		CreatedAt: job.CreatedAt,
+
+		MaxQueueTime: job.MaxQueueTime,
+		MaxRunTime:   job.MaxRunTime,
+
+		Flavor:    job.Flavor,
+		NodeCount: job.NodeCount,
+		CardNum:   job.CardNum,
+	}
+}
+
+func toKeyValueDOs(kv []domain.KeyValue) []keyValueDO {
+	if len(kv) == 0 {
+		return nil
+	}
+
+	r := make([]keyValueDO, len(kv))
+	for i := range kv {
+		r[i].Key = kv[i].Key.CustomizedKey()
+
+		if kv[i].Value != nil {
+			r[i].Value = kv[i].Value.CustomizedValue()
+		}
+	}
+
+	return r
+}
+
+func toJobDetailDO(detail *domain.JobDetail, do *jobDetailDO) {
+	*do = jobDetailDO{
+		Error:      detail.Error,
+		Category:   detail.ErrorCategory,
+		Reason:     detail.TerminationReason,
+		LogPath:    detail.LogPath,
+		OutputPath: detail.OutputPath,
+		Duration:   detail.Duration,
+		StartedAt:  detail.StartedAt,
+		Metrics:    detail.Metrics,
+		Progress:   toProgressDO(&detail.Progress),
+	}
+
+	if n := len(detail.ProgressHistory); n > 0 {
+		do.History = make([]progressDO, n)
+		for i := range detail.ProgressHistory {
+			do.History[i] = progressDO(detail.ProgressHistory[i])
+		}
+	}
+
+	if detail.Status != nil {
+		do.Status = detail.Status.TrainingStatus()
+	}
+
+	if n := len(detail.Attempts); n > 0 {
+		do.Attempts = make([]attemptDO, n)
+		for i, v := range detail.Attempts {
+			do.Attempts[i] = attemptDO{
+				JobId:     v.JobId,
+				Pool:      v.Pool,
+				Status:    v.Status.TrainingStatus(),
+				Duration:  v.Duration,
+				CreatedAt: v.CreatedAt,
+				StartedAt: v.StartedAt,
+			}
+		}
+	}
+
+	if n := len(detail.Images); n > 0 {
+		do.Images = make([]imageDO, n)
+		for i, v := range detail.Images {
+			do.Images[i] = imageDO{Path: v.Path, Thumbnail: v.Thumbnail}
+		}
+	}
+}
+
+func (do *finetuneDO) toFinetune(t *domain.AICCFinetune) (err error) {
+	t.Id = do.Id
+	t.Parent = do.Parent
+	t.Checkpoint = do.Checkpoint
+	t.Resume = do.Resume
+	t.Children = do.Children
+	t.Schedule = domain.JobSchedule{Cron: do.Cron, SubmitAt: do.SubmitAt}
+	t.Pipeline = do.Pipeline
+
+	if t.User, err = domain.NewAccount(do.User); err != nil {
+		return
+	}
+
+	if t.Model, err = domain.NewModelName(do.Model); err != nil {
+		return
+	}
+
+	if t.Task, err = domain.NewTaskType(do.Task); err != nil {
+		return
+	}
+
+	if err = do.configDO.toConfig(&t.AICCFinetuneConfig); err != nil {
+		return
+	}
+
+	t.Job = do.Job.toJobInfo()
+
+	return do.JobDetail.toJobDetail(&t.JobDetail)
+}
+
+func (do *jobInfoDO) toJobInfo() domain.JobInfo {
+	return domain.JobInfo{
+		Endpoint:  do.Endpoint,
+		JobId:     do.JobId,
+		LogDir:    do.LogDir,
+		OutputDir: do.OutputDir,
+		Pool:      do.Pool,
+		CreatedAt: do.CreatedAt,
+
+		MaxQueueTime: do.MaxQueueTime,
+		MaxRunTime:   do.MaxRunTime,
+
+		Flavor:    do.Flavor,
+		NodeCount: do.NodeCount,
+		CardNum:   do.CardNum,
+	}
+}
+
+func (do *configDO) toConfig(t *domain.AICCFinetuneConfig) (err error) {
+	t.PriorityClass = do.PriorityClass
+
+	if t.Name, err = domain.NewFinetuneName(do.Name); err != nil {
+		return
+	}
+
+	if t.Desc, err = domain.NewFinetuneDesc(do.Desc); err != nil {
+		return
+	}
+
+	if t.EvalSet, err = domain.NewDirectory(do.EvalSet); err != nil {
+		return
+	}
+
+	if v := do.Inference; v != nil {
+		t.Inference, err = domain.NewInferenceOption(
+			v.Prompts, v.NegativePrompts,
+			v.Seed, v.SampleCount, v.Width, v.Height,
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	if t.Resource, err = domain.NewJobResource(do.Flavor, do.NodeCount); err != nil {
+		return
+	}
+
+	if v := do.Retry; v != nil {
+		if t.Retry, err = domain.NewRetryPolicy(v.MaxRetries, v.Statuses); err != nil {
+			return
+		}
+	}
+
+	if t.Limits, err = domain.NewJobLimits(do.MaxQueueTime, do.MaxRunTime); err != nil {
+		return
+	}
+
+	if t.Hyperparameters, err = toKeyValues(do.Hyperparameters); err != nil {
+		return
+	}
+
+	t.Env, err = toKeyValues(do.Env)
+
+	return
+}
+
+func toKeyValues(kv []keyValueDO) (r []domain.KeyValue, err error) {
+	if len(kv) == 0 {
+		return
+	}
+
+	r = make([]domain.KeyValue, len(kv))
+	for i := range kv {
+		if r[i].Key, err = domain.NewCustomizedKey(kv[i].Key); err != nil {
+			return
+		}
+
+		if r[i].Value, err = domain.NewCustomizedValue(kv[i].Value); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (do *jobDetailDO) toJobDetail(detail *domain.JobDetail) (err error) {
+	*detail = domain.JobDetail{
+		Error:             do.Error,
+		ErrorCategory:     do.Category,
+		TerminationReason: do.Reason,
+		LogPath:           do.LogPath,
+		OutputPath:        do.OutputPath,
+		Duration:          do.Duration,
+		StartedAt:         do.StartedAt,
+		Metrics:           do.Metrics,
+		Progress:          do.Progress.toProgress(),
+	}
+
+	if n := len(do.History); n > 0 {
+		detail.ProgressHistory = make([]domain.Progress, n)
+		for i := range do.History {
+			detail.ProgressHistory[i] = do.History[i].toProgress()
+		}
+	}
+
+	if n := len(do.Attempts); n > 0 {
+		detail.Attempts = make([]domain.JobAttempt, n)
+		for i, v := range do.Attempts {
+			a := &detail.Attempts[i]
+
+			if a.Status, err = domain.NewTrainingStatus(v.Status); err != nil {
+				return
+			}
+
+			a.JobId = v.JobId
+			a.Pool = v.Pool
+			a.Duration = v.Duration
+			a.CreatedAt = v.CreatedAt
+			a.StartedAt = v.StartedAt
+		}
+	}
+
+	if n := len(do.Images); n > 0 {
+		detail.Images = make([]domain.InferenceImage, n)
+		for i, v := range do.Images {
+			detail.Images[i] = domain.InferenceImage{
+				Path:      v.Path,
+				Thumbnail: v.Thumbnail,
+			}
+		}
+	}
+
+	if do.Status != "" {
+		detail.Status, err = domain.NewTrainingStatus(do.Status)
+	}
+
+	return
+}
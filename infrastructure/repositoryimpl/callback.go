@@ -0,0 +1,134 @@
+package repositoryimpl
+
+import (
+	"path/filepath"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+func NewCallbackOutboxRepository(cfg *Config) (repository.CallbackOutbox, error) {
+	s, err := newFileStore(filepath.Join(cfg.Dir, "callback"))
+	if err != nil {
+		return nil, err
+	}
+
+	return callbackRepoImpl{s}, nil
+}
+
+type callbackRepoImpl struct {
+	store *fileStore
+}
+
+func (impl callbackRepoImpl) Save(t *watch.CallbackEvent) error {
+	do := new(callbackEventDO)
+	toCallbackEventDO(t, do)
+
+	return impl.store.save(t.Id, do)
+}
+
+func (impl callbackRepoImpl) Get(eventId string) (r watch.CallbackEvent, err error) {
+	do := new(callbackEventDO)
+	if err = impl.store.get(eventId, do); err != nil {
+		return
+	}
+
+	do.toCallbackEvent(&r)
+
+	return
+}
+
+func (impl callbackRepoImpl) FindAll() ([]watch.CallbackEvent, error) {
+	ids, err := impl.store.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]watch.CallbackEvent, 0, len(ids))
+	for _, id := range ids {
+		v, err := impl.Get(id)
+		if err != nil {
+			if repository.IsErrorResourceNotExists(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		r = append(r, v)
+	}
+
+	return r, nil
+}
+
+func (impl callbackRepoImpl) Remove(eventId string) error {
+	return impl.store.remove(eventId)
+}
+
+type callbackEventDO struct {
+	Id            string      `json:"id"`
+	FinetuneId    string      `json:"finetune_id"`
+	User          string      `json:"user"`
+	Model         string      `json:"model"`
+	Seq           int64       `json:"seq"`
+	Status        string      `json:"status"`
+	Duration      int         `json:"duration"`
+	LogPath       string      `json:"log_path,omitempty"`
+	OutputZipPath string      `json:"output_zip_path,omitempty"`
+	Progress      *progressDO `json:"progress,omitempty"`
+	Final         bool        `json:"final"`
+	Attempts      int         `json:"attempts"`
+	NextAttemptAt int64       `json:"next_attempt_at"`
+	LastError     string      `json:"last_error,omitempty"`
+	Dead          bool        `json:"dead"`
+	Superseded    bool        `json:"superseded,omitempty"`
+	CreatedAt     int64       `json:"created_at"`
+}
+
+func toCallbackEventDO(t *watch.CallbackEvent, do *callbackEventDO) {
+	*do = callbackEventDO{
+		Id:            t.Id,
+		FinetuneId:    t.FinetuneId,
+		User:          t.User,
+		Model:         t.Model,
+		Seq:           t.Seq,
+		Status:        t.Status,
+		Duration:      t.Duration,
+		LogPath:       t.LogPath,
+		OutputZipPath: t.OutputZipPath,
+		Progress:      toProgressDO(t.Progress),
+		Final:         t.Final,
+		Attempts:      t.Attempts,
+		NextAttemptAt: t.NextAttemptAt,
+		LastError:     t.LastError,
+		Dead:          t.Dead,
+		Superseded:    t.Superseded,
+		CreatedAt:     t.CreatedAt,
+	}
+}
+
+func (do *callbackEventDO) toCallbackEvent(t *watch.CallbackEvent) {
+	*t = watch.CallbackEvent{
+		Id:            do.Id,
+		FinetuneId:    do.FinetuneId,
+		User:          do.User,
+		Model:         do.Model,
+		Seq:           do.Seq,
+		Status:        do.Status,
+		Duration:      do.Duration,
+		LogPath:       do.LogPath,
+		OutputZipPath: do.OutputZipPath,
+		Final:         do.Final,
+		Attempts:      do.Attempts,
+		NextAttemptAt: do.NextAttemptAt,
+		LastError:     do.LastError,
+		Dead:          do.Dead,
+		Superseded:    do.Superseded,
+		CreatedAt:     do.CreatedAt,
+	}
+
+	if do.Progress != nil {
+		p := do.Progress.toProgress()
+		t.Progress = &p
+	}
+}
@@ -0,0 +1,8 @@
+package repositoryimpl
+
+type Config struct {
+	// Dir specifies the directory where the records are stored.
+	// It should be on a persistent volume. If it is shared by the
+	// replicas, it should support flock, such as NFSv4.
+	Dir string `json:"dir" required:"true"`
+}
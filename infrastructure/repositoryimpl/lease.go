@@ -0,0 +1,78 @@
+package repositoryimpl
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+func NewLeaseRepository(cfg *Config) (repository.Lease, error) {
+	s, err := newFileStore(filepath.Join(cfg.Dir, "lease"))
+	if err != nil {
+		return nil, err
+	}
+
+	return leaseRepoImpl{s}, nil
+}
+
+// leaseRepoImpl stores the lease in the dir shared by the replicas.
+// The lease is read and written under the lock file, so the replicas
+// will not acquire it at the same time.
+type leaseRepoImpl struct {
+	store *fileStore
+}
+
+type leaseDO struct {
+	Holder string `json:"holder"`
+
+	// ExpiresAt is the unix time in millisecond.
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+func (impl leaseRepoImpl) TryAcquire(name, holder string, duration time.Duration) (b bool, err error) {
+	err = impl.store.exclusive(name, func() error {
+		now := time.Now()
+
+		do := new(leaseDO)
+		if err := impl.store.read(name, do); err != nil {
+			if !repository.IsErrorResourceNotExists(err) {
+				return err
+			}
+		} else if do.Holder != holder && do.ExpiresAt > now.UnixMilli() {
+			return nil
+		}
+
+		do.Holder = holder
+		do.ExpiresAt = now.Add(duration).UnixMilli()
+
+		if err := impl.store.write(name, do); err != nil {
+			return err
+		}
+
+		b = true
+
+		return nil
+	})
+
+	return
+}
+
+func (impl leaseRepoImpl) Release(name, holder string) error {
+	return impl.store.exclusive(name, func() error {
+		do := new(leaseDO)
+		if err := impl.store.read(name, do); err != nil {
+			if repository.IsErrorResourceNotExists(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		if do.Holder != holder {
+			return nil
+		}
+
+		return impl.store.removeFile(name)
+	})
+}
@@ -0,0 +1,173 @@
+package repositoryimpl
+
+import (
+	"path/filepath"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+func NewPipelineRepository(cfg *Config) (repository.Pipeline, error) {
+	s, err := newFileStore(filepath.Join(cfg.Dir, "pipeline"))
+	if err != nil {
+		return nil, err
+	}
+
+	return pipelineRepoImpl{s}, nil
+}
+
+type pipelineRepoImpl struct {
+	store *fileStore
+}
+
+func (impl pipelineRepoImpl) Save(t *domain.Pipeline) error {
+	do := new(pipelineDO)
+	toPipelineDO(t, do)
+
+	return impl.store.save(t.Id, do)
+}
+
+func (impl pipelineRepoImpl) Get(pipelineId string) (r domain.Pipeline, err error) {
+	do := new(pipelineDO)
+	if err = impl.store.get(pipelineId, do); err != nil {
+		return
+	}
+
+	err = do.toPipeline(&r)
+
+	return
+}
+
+func (impl pipelineRepoImpl) FindAll() ([]domain.Pipeline, error) {
+	ids, err := impl.store.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]domain.Pipeline, 0, len(ids))
+	for _, id := range ids {
+		v, err := impl.Get(id)
+		if err != nil {
+			if repository.IsErrorResourceNotExists(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		r = append(r, v)
+	}
+
+	return r, nil
+}
+
+func (impl pipelineRepoImpl) Update(pipelineId string, f func(*domain.Pipeline) error) error {
+	do := new(pipelineDO)
+
+	return impl.store.update(pipelineId, do, func() error {
+		var p domain.Pipeline
+		if err := do.toPipeline(&p); err != nil {
+			return err
+		}
+
+		if err := f(&p); err != nil {
+			return err
+		}
+
+		toPipelineDO(&p, do)
+
+		return nil
+	})
+}
+
+type pipelineDO struct {
+	Id        string           `json:"id"`
+	User      string           `json:"user"`
+	Model     string           `json:"model"`
+	Steps     []pipelineStepDO `json:"steps"`
+	Canceled  bool             `json:"canceled"`
+	Settled   bool             `json:"settled,omitempty"`
+	CreatedAt int64            `json:"created_at"`
+}
+
+type pipelineStepDO struct {
+	Name      string   `json:"name"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Task      string   `json:"task"`
+	Parent    string   `json:"parent,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Attempts  int      `json:"attempts,omitempty"`
+
+	configDO
+}
+
+func toPipelineDO(t *domain.Pipeline, do *pipelineDO) {
+	*do = pipelineDO{
+		Id:        t.Id,
+		User:      t.User.Account(),
+		Model:     t.Model.ModelName(),
+		Steps:     make([]pipelineStepDO, len(t.Steps)),
+		Canceled:  t.Canceled,
+		Settled:   t.Settled,
+		CreatedAt: t.CreatedAt,
+	}
+
+	for i := range t.Steps {
+		item := &t.Steps[i]
+		step := &do.Steps[i]
+
+		*step = pipelineStepDO{
+			Name:      item.Name,
+			DependsOn: item.DependsOn,
+			Task:      item.Finetune.Task.TaskType(),
+			Parent:    item.Finetune.Parent,
+			Error:     item.Error,
+			Attempts:  item.Attempts,
+		}
+
+		toConfigDO(&item.Finetune.AICCFinetuneConfig, &step.configDO)
+	}
+}
+
+func (do *pipelineDO) toPipeline(t *domain.Pipeline) (err error) {
+	t.Id = do.Id
+	t.Canceled = do.Canceled
+	t.Settled = do.Settled
+	t.CreatedAt = do.CreatedAt
+
+	if t.User, err = domain.NewAccount(do.User); err != nil {
+		return
+	}
+
+	if t.Model, err = domain.NewModelName(do.Model); err != nil {
+		return
+	}
+
+	t.Steps = make([]domain.PipelineStep, len(do.Steps))
+	for i := range do.Steps {
+		item := &do.Steps[i]
+		step := &t.Steps[i]
+
+		step.Name = item.Name
+		step.DependsOn = item.DependsOn
+		step.Error = item.Error
+		step.Attempts = item.Attempts
+
+		f := &step.Finetune
+		f.Id = domain.StepFinetuneId(do.Id, item.Name)
+		f.User = t.User
+		f.Model = t.Model
+		f.Parent = item.Parent
+		f.Pipeline = do.Id
+
+		if f.Task, err = domain.NewTaskType(item.Task); err != nil {
+			return
+		}
+
+		if err = item.configDO.toConfig(&f.AICCFinetuneConfig); err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,209 @@
+package repositoryimpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+var reDocId = regexp.MustCompile("^[a-zA-Z0-9_-]+$")
+
+func newFileStore(dir string) (*fileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &fileStore{dir: dir}, nil
+}
+
+// fileStore stores each document as a json file in the dir. The dir may
+// be shared by the replicas of service, so the document is changed under
+// the lock file of it besides the lock in process, see lockFile.
+type fileStore struct {
+	dir  string
+	lock sync.Mutex
+}
+
+func (s *fileStore) path(id string) (string, error) {
+	if !reDocId.MatchString(id) {
+		return "", fmt.Errorf("invalid document id: %s", id)
+	}
+
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func (s *fileStore) read(id string, v interface{}) error {
+	p, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return repository.NewErrorResourceNotExists(
+				fmt.Errorf("%s not exists", id),
+			)
+		}
+
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func (s *fileStore) write(id string, v interface{}) error {
+	p, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(s.dir, "."+id)
+	if err != nil {
+		return err
+	}
+
+	tmp := f.Name()
+
+	_, err = f.Write(b)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, p)
+	}
+
+	if err != nil {
+		os.Remove(tmp)
+	}
+
+	return err
+}
+
+func (s *fileStore) get(id string, v interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.read(id, v)
+}
+
+func (s *fileStore) save(id string, v interface{}) error {
+	return s.exclusive(id, func() error {
+		return s.write(id, v)
+	})
+}
+
+// update reads the document to v, changes it by f and writes it back.
+func (s *fileStore) update(id string, v interface{}, f func() error) error {
+	return s.exclusive(id, func() error {
+		if err := s.read(id, v); err != nil {
+			return err
+		}
+
+		if err := f(); err != nil {
+			return err
+		}
+
+		return s.write(id, v)
+	})
+}
+
+// remove deletes the document. It is fine if the document not exists.
+func (s *fileStore) remove(id string) error {
+	return s.exclusive(id, func() error {
+		return s.removeFile(id)
+	})
+}
+
+func (s *fileStore) removeFile(id string) error {
+	p, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// exclusive runs f under the lock file of document, so that f is not run
+// by the other processes sharing the dir at the same time. f should use
+// read and write which don't lock in process.
+func (s *fileStore) exclusive(id string, f func() error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	unlock, err := s.lockFile(id)
+	if err != nil {
+		return fmt.Errorf("lock %s failed, err:%s", id, err.Error())
+	}
+
+	defer unlock()
+
+	return f()
+}
+
+// lockFile takes the flock of the lock file of document. The lock file is
+// kept, since removing it would let two processes lock different files.
+// The lock is released by the kernel if the process crashes, so it never
+// goes stale. The dir should support flock if it is shared, such as NFSv4.
+func (s *fileStore) lockFile(id string) (func(), error) {
+	p, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(p+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+// ids returns the ids of all documents.
+func (s *fileStore) ids() ([]string, error) {
+	s.lock.Lock()
+	items, err := ioutil.ReadDir(s.dir)
+	s.lock.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]string, 0, len(items))
+	for _, item := range items {
+		name := item.Name()
+
+		if id := strings.TrimSuffix(name, ".json"); id != name && reDocId.MatchString(id) {
+			r = append(r, id)
+		}
+	}
+
+	return r, nil
+}
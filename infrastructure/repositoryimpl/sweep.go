@@ -0,0 +1,173 @@
+package repositoryimpl
+
+import (
+	"path/filepath"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+func NewSweepRepository(cfg *Config) (repository.Sweep, error) {
+	s, err := newFileStore(filepath.Join(cfg.Dir, "sweep"))
+	if err != nil {
+		return nil, err
+	}
+
+	return sweepRepoImpl{s}, nil
+}
+
+type sweepRepoImpl struct {
+	store *fileStore
+}
+
+func (impl sweepRepoImpl) Save(t *domain.Sweep) error {
+	do := new(sweepDO)
+	toSweepDO(t, do)
+
+	return impl.store.save(t.Id, do)
+}
+
+func (impl sweepRepoImpl) Get(sweepId string) (r domain.Sweep, err error) {
+	do := new(sweepDO)
+	if err = impl.store.get(sweepId, do); err != nil {
+		return
+	}
+
+	err = do.toSweep(&r)
+
+	return
+}
+
+type sweepDO struct {
+	Id    string `json:"id"`
+	User  string `json:"user"`
+	Model string `json:"model"`
+	Task  string `json:"task"`
+
+	configDO
+
+	Strategy  string         `json:"strategy"`
+	Params    []sweepParamDO `json:"params"`
+	MaxTrials int            `json:"max_trials"`
+	Seed      int64          `json:"seed"`
+	Metric    string         `json:"metric"`
+	Maximize  bool           `json:"maximize"`
+	Trials    []sweepTrialDO `json:"trials"`
+	CreatedAt int64          `json:"created_at"`
+}
+
+type sweepParamDO struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+type sweepTrialDO struct {
+	FinetuneId      string       `json:"finetune_id"`
+	Hyperparameters []keyValueDO `json:"hyperparameters"`
+	Error           string       `json:"error,omitempty"`
+}
+
+func toSweepDO(t *domain.Sweep, do *sweepDO) {
+	*do = sweepDO{
+		Id:        t.Id,
+		User:      t.User.Account(),
+		Model:     t.Model.ModelName(),
+		Task:      t.Task.TaskType(),
+		Strategy:  t.Space.Strategy,
+		Params:    make([]sweepParamDO, len(t.Space.Params)),
+		MaxTrials: t.Space.MaxTrials,
+		Seed:      t.Space.Seed,
+		Metric:    t.Objective.Metric,
+		Maximize:  t.Objective.Maximize,
+		Trials:    make([]sweepTrialDO, len(t.Trials)),
+		CreatedAt: t.CreatedAt,
+	}
+
+	toConfigDO(&t.AICCFinetuneConfig, &do.configDO)
+
+	for i := range t.Space.Params {
+		p := &t.Space.Params[i]
+
+		v := make([]string, len(p.Values))
+		for j := range p.Values {
+			if p.Values[j] != nil {
+				v[j] = p.Values[j].CustomizedValue()
+			}
+		}
+
+		do.Params[i] = sweepParamDO{
+			Key:    p.Key.CustomizedKey(),
+			Values: v,
+		}
+	}
+
+	for i := range t.Trials {
+		item := &t.Trials[i]
+
+		do.Trials[i] = sweepTrialDO{
+			FinetuneId:      item.FinetuneId,
+			Hyperparameters: toKeyValueDOs(item.Hyperparameters),
+			Error:           item.Error,
+		}
+	}
+}
+
+func (do *sweepDO) toSweep(t *domain.Sweep) (err error) {
+	t.Id = do.Id
+	t.CreatedAt = do.CreatedAt
+
+	if t.User, err = domain.NewAccount(do.User); err != nil {
+		return
+	}
+
+	if t.Model, err = domain.NewModelName(do.Model); err != nil {
+		return
+	}
+
+	if t.Task, err = domain.NewTaskType(do.Task); err != nil {
+		return
+	}
+
+	if err = do.configDO.toConfig(&t.AICCFinetuneConfig); err != nil {
+		return
+	}
+
+	params := make([]domain.SweepParam, len(do.Params))
+	for i := range do.Params {
+		p := &do.Params[i]
+
+		if params[i].Key, err = domain.NewCustomizedKey(p.Key); err != nil {
+			return
+		}
+
+		params[i].Values = make([]domain.CustomizedValue, len(p.Values))
+		for j, v := range p.Values {
+			if params[i].Values[j], err = domain.NewCustomizedValue(v); err != nil {
+				return
+			}
+		}
+	}
+
+	t.Space, err = domain.NewSearchSpace(do.Strategy, params, do.MaxTrials, do.Seed)
+	if err != nil {
+		return
+	}
+
+	if t.Objective, err = domain.NewSweepObjective(do.Metric, do.Maximize); err != nil {
+		return
+	}
+
+	t.Trials = make([]domain.SweepTrial, len(do.Trials))
+	for i := range do.Trials {
+		item := &do.Trials[i]
+
+		t.Trials[i].FinetuneId = item.FinetuneId
+		t.Trials[i].Error = item.Error
+
+		if t.Trials[i].Hyperparameters, err = toKeyValues(item.Hyperparameters); err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,75 @@
+package repositoryimpl
+
+import (
+	"path/filepath"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+)
+
+func NewUsageRepository(cfg *Config) (repository.Usage, error) {
+	s, err := newFileStore(filepath.Join(cfg.Dir, "usage"))
+	if err != nil {
+		return nil, err
+	}
+
+	return usageRepoImpl{s}, nil
+}
+
+// usageRepoImpl stores the usage of each job in a file named by job id.
+type usageRepoImpl struct {
+	store *fileStore
+}
+
+func (impl usageRepoImpl) Save(u *domain.Usage) error {
+	do := new(usageDO)
+	toUsageDO(u, do)
+
+	return impl.store.save(u.JobId, do)
+}
+
+func (impl usageRepoImpl) FindAll() ([]domain.Usage, error) {
+	ids, err := impl.store.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]domain.Usage, 0, len(ids))
+	for _, id := range ids {
+		do := new(usageDO)
+		if err := impl.store.get(id, do); err != nil {
+			if repository.IsErrorResourceNotExists(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		r = append(r, domain.Usage{})
+		do.toUsage(&r[len(r)-1])
+	}
+
+	return r, nil
+}
+
+type usageDO struct {
+	JobId      string `json:"job_id"`
+	FinetuneId string `json:"finetune_id"`
+	User       string `json:"user"`
+	Model      string `json:"model"`
+	Task       string `json:"task"`
+	Status     string `json:"status"`
+	Flavor     string `json:"flavor,omitempty"`
+	NodeCount  int    `json:"node_count"`
+	CardNum    int    `json:"card_num"`
+	Duration   int    `json:"duration"`
+	FinishedAt int64  `json:"finished_at"`
+}
+
+func toUsageDO(u *domain.Usage, do *usageDO) {
+	*do = usageDO(*u)
+}
+
+func (do *usageDO) toUsage(u *domain.Usage) {
+	*u = domain.Usage(*do)
+}
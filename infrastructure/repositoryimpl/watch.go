@@ -0,0 +1,132 @@
+package repositoryimpl
+
+import (
+	"path/filepath"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+func NewWatchStateRepository(cfg *Config) (repository.WatchState, error) {
+	s, err := newFileStore(filepath.Join(cfg.Dir, "watch"))
+	if err != nil {
+		return nil, err
+	}
+
+	return watchStateRepoImpl{s}, nil
+}
+
+type watchStateRepoImpl struct {
+	store *fileStore
+}
+
+func (impl watchStateRepoImpl) Save(t *watch.WatchState) error {
+	do := new(watchStateDO)
+	toWatchStateDO(t, do)
+
+	return impl.store.save(t.FinetuneId, do)
+}
+
+func (impl watchStateRepoImpl) FindAll() ([]watch.WatchState, error) {
+	ids, err := impl.store.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]watch.WatchState, 0, len(ids))
+	for _, id := range ids {
+		do := new(watchStateDO)
+		if err := impl.store.get(id, do); err != nil {
+			if repository.IsErrorResourceNotExists(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		v := watch.WatchState{}
+		if err := do.toWatchState(&v); err != nil {
+			return nil, err
+		}
+
+		r = append(r, v)
+	}
+
+	return r, nil
+}
+
+func (impl watchStateRepoImpl) Remove(finetuneId string) error {
+	return impl.store.remove(finetuneId)
+}
+
+type watchStateDO struct {
+	FinetuneId    string    `json:"finetune_id"`
+	User          string    `json:"user"`
+	Model         string    `json:"model,omitempty"`
+	Task          string    `json:"task"`
+	Job           jobInfoDO `json:"job"`
+	PriorityClass string    `json:"priority_class,omitempty"`
+	PendingSince  int64     `json:"pending_since"`
+	QueuedSince   int64     `json:"queued_since"`
+	Done          bool      `json:"done"`
+	Success       bool      `json:"success"`
+	LogDone       bool      `json:"log_done"`
+	OutputDone    bool      `json:"output_done"`
+	MetricsDone   bool      `json:"metrics_done"`
+	ImagesDone    bool      `json:"images_done"`
+}
+
+func toWatchStateDO(t *watch.WatchState, do *watchStateDO) {
+	*do = watchStateDO{
+		FinetuneId:    t.FinetuneId,
+		User:          t.User.Account(),
+		Task:          t.Task.TaskType(),
+		PriorityClass: t.PriorityClass,
+		PendingSince:  t.PendingSince,
+		QueuedSince:   t.QueuedSince,
+		Done:          t.Done,
+		Success:       t.Success,
+		LogDone:       t.LogDone,
+		OutputDone:    t.OutputDone,
+		MetricsDone:   t.MetricsDone,
+		ImagesDone:    t.ImagesDone,
+	}
+
+	if t.Model != nil {
+		do.Model = t.Model.ModelName()
+	}
+
+	toJobInfoDO(&t.JobInfo, &do.Job)
+}
+
+func (do *watchStateDO) toWatchState(t *watch.WatchState) (err error) {
+	*t = watch.WatchState{
+		PriorityClass: do.PriorityClass,
+		PendingSince:  do.PendingSince,
+		QueuedSince:   do.QueuedSince,
+		Done:          do.Done,
+		Success:       do.Success,
+		LogDone:       do.LogDone,
+		OutputDone:    do.OutputDone,
+		MetricsDone:   do.MetricsDone,
+		ImagesDone:    do.ImagesDone,
+	}
+
+	t.FinetuneId = do.FinetuneId
+	t.JobInfo = do.Job.toJobInfo()
+
+	if t.User, err = domain.NewAccount(do.User); err != nil {
+		return
+	}
+
+	if do.Model != "" {
+		if t.Model, err = domain.NewModelName(do.Model); err != nil {
+			return
+		}
+	}
+
+	t.Task, err = domain.NewTaskType(do.Task)
+
+	return
+}
@@ -0,0 +1,55 @@
+package statussinkimpl
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	sinkGRPC = "grpc"
+	sinkHTTP = "http"
+	sinkLog  = "log"
+)
+
+type Config struct {
+	// Type specifies where the status of finetunes is reported.
+	// It can be grpc, http or log. log only writes the status
+	// to the log, and is useful without the xihe server.
+	Type string `json:"type"`
+
+	// Endpoint is the address of grpc server
+	// or the url of http callback.
+	Endpoint string `json:"endpoint"`
+
+	// Token is put in the header of http callback if set.
+	Token string `json:"token"`
+
+	// Timeout specifies the timeout of http callback. The unit is second.
+	Timeout int `json:"timeout"`
+}
+
+func (cfg *Config) SetDefault() {
+	if cfg.Type == "" {
+		cfg.Type = sinkGRPC
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+}
+
+func (cfg *Config) Validate() error {
+	switch cfg.Type {
+	case sinkGRPC, sinkHTTP:
+		if cfg.Endpoint == "" {
+			return errors.New("missing endpoint of status sink")
+		}
+
+	case sinkLog:
+
+	default:
+		return fmt.Errorf("unsupported status sink: %s", cfg.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package statussinkimpl
+
+import (
+	pt "github.com/opensourceways/xihe-grpc-protocol/grpc/aiccfinetune"
+	"github.com/opensourceways/xihe-grpc-protocol/grpc/client"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+func newGRPCSink(endpoint string) (grpcSink, error) {
+	cli, err := client.NewAICCFinetuneClient(endpoint)
+	if err != nil {
+		return grpcSink{}, err
+	}
+
+	return grpcSink{cli}, nil
+}
+
+// grpcSink reports the status to the xihe server by grpc.
+type grpcSink struct {
+	cli *client.AICCFinetuneClient
+}
+
+func (s grpcSink) Report(v *watch.FinetuneStatus) error {
+	index := pt.AICCFinetuneIndex{
+		Id:    v.FinetuneId,
+		User:  v.User,
+		Model: v.Model,
+	}
+
+	info := pt.AICCFinetuneInfo{
+		Status:        v.Status,
+		Duration:      v.Duration,
+		LogPath:       v.LogPath,
+		OutputZipPath: v.OutputZipPath,
+	}
+
+	return s.cli.SetAICCFinetuneInfo(&index, &info)
+}
+
+func (s grpcSink) Close() error {
+	return s.cli.Disconnect()
+}
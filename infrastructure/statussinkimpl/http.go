@@ -0,0 +1,63 @@
+package statussinkimpl
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/utils"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+func newHTTPSink(cfg *Config) httpSink {
+	return httpSink{
+		url:   cfg.Endpoint,
+		token: cfg.Token,
+		cli: &http.Client{
+			Timeout: time.Duration(cfg.Timeout) * time.Second,
+		},
+	}
+}
+
+// httpSink posts the status as json to the callback url.
+type httpSink struct {
+	url   string
+	token string
+	cli   *http.Client
+}
+
+func (s httpSink) Report(v *watch.FinetuneStatus) error {
+	payload, err := utils.JsonMarshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback failed, status:%s", resp.Status)
+	}
+
+	return nil
+}
+
+func (s httpSink) Close() error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package statussinkimpl
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+func NewStatusSink(cfg *Config, log *logrus.Entry) (watch.StatusSink, error) {
+	switch cfg.Type {
+	case sinkGRPC:
+		return newGRPCSink(cfg.Endpoint)
+
+	case sinkHTTP:
+		return newHTTPSink(cfg), nil
+
+	case sinkLog:
+		return logSink{log}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported status sink: %s", cfg.Type)
+}
+
+// logSink only writes the status to the log.
+type logSink struct {
+	log *logrus.Entry
+}
+
+func (s logSink) Report(v *watch.FinetuneStatus) error {
+	s.log.Infof(
+		"finetune(%s) of %s, model:%s, status:%s, duration:%d, log:%s, output:%s",
+		v.FinetuneId, v.User, v.Model, v.Status, v.Duration, v.LogPath, v.OutputZipPath,
+	)
+
+	return nil
+}
+
+func (s logSink) Close() error {
+	return nil
+}
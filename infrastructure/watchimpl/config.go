@@ -1,19 +1,64 @@
 package watchimpl
 
+import (
+	"errors"
+	"fmt"
+)
+
 type Config struct {
-	// Interval specifies the interval of second between two loops
-	// that check all finetunes in a loop.
+	// Interval specifies the interval of second between two checks
+	// of a finetune. It is adjusted by the phase of job, see Polling.
 	Interval int `json:"interval"`
 
-	// Timeout specifies the time that a finetune can live
-	// The unit is second.
+	Polling PollingConfig `json:"polling"`
+
+	Callback CallbackConfig `json:"callback"`
+
+	// ProgressInterval specifies the interval of second between two reads
+	// of the training progress of a running job. It is not read if it is -1.
+	ProgressInterval int `json:"progress_interval"`
+
+	// Timeout specifies the time that a finetune can run if
+	// the job has no max run time. The unit is second.
 	Timeout int `json:"timeout"`
 
+	// PendingThreshold specifies the time that a job can be pending
+	// before it is resubmitted to another pool. The unit is second.
+	// It will not resubmit the job if it is 0.
+	PendingThreshold int `json:"pending_threshold"`
+
 	// MaxWatchNum specifies the max num of finetune
 	// which the aicc finetune center can support
 	MaxWatchNum int `json:"max_watch_num"`
 
-	Endpoint string `json:"endpoint" required:"true"`
+	// Workers specifies the num of workers which check
+	// the status of finetunes concurrently.
+	Workers int `json:"workers"`
+
+	// OutputWorkers specifies the num of workers which
+	// process the outputs of finetunes, such as packing.
+	OutputWorkers int `json:"output_workers"`
+
+	// ShutdownTimeout specifies the time to wait for the workers
+	// to finish the current work when exiting. The unit is second.
+	// It should be less than the grace period of service.
+	ShutdownTimeout int `json:"shutdown_timeout"`
+
+	// PriorityClasses specifies the classes of finetune for admission.
+	// All the finetunes are admitted equally if it is empty.
+	PriorityClasses []PriorityClass `json:"priority_classes"`
+
+	// DefaultPriorityClass is the class of finetune which
+	// matches no class. It is the lowest class if empty.
+	DefaultPriorityClass string `json:"default_priority_class"`
+
+	// Preemption allows the finetune to terminate a preemptible
+	// finetune of lower class if there is no slot for it.
+	Preemption bool `json:"preemption"`
+
+	// Endpoint is the address of xihe grpc server.
+	// Deprecated: use the endpoint of status sink instead.
+	Endpoint string `json:"endpoint"`
 }
 
 func (cfg *Config) SetDefault() {
@@ -25,7 +70,233 @@ func (cfg *Config) SetDefault() {
 		cfg.Timeout = 864000
 	}
 
+	if cfg.ProgressInterval == 0 {
+		cfg.ProgressInterval = 60
+	}
+
+	cfg.Polling.setDefault(cfg.Interval)
+	cfg.Callback.setDefault()
+
 	if cfg.MaxWatchNum <= 0 {
 		cfg.MaxWatchNum = 100
 	}
+
+	if cfg.Workers <= 0 {
+		cfg.Workers = 8
+	}
+
+	if cfg.OutputWorkers <= 0 {
+		cfg.OutputWorkers = 2
+	}
+
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 30
+	}
+
+	for i := range cfg.PriorityClasses {
+		if v := &cfg.PriorityClasses[i]; v.AdmitRatio <= 0 || v.AdmitRatio > 100 {
+			v.AdmitRatio = 100
+		}
+	}
+
+	if cfg.DefaultPriorityClass == "" && len(cfg.PriorityClasses) > 0 {
+		c := &cfg.PriorityClasses[0]
+		for i := range cfg.PriorityClasses {
+			if v := &cfg.PriorityClasses[i]; v.Value < c.Value {
+				c = v
+			}
+		}
+
+		cfg.DefaultPriorityClass = c.Name
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if err := cfg.Polling.validate(cfg.Interval); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(cfg.PriorityClasses))
+
+	for i := range cfg.PriorityClasses {
+		name := cfg.PriorityClasses[i].Name
+		if name == "" {
+			return errors.New("missing name of priority class")
+		}
+
+		if names[name] {
+			return fmt.Errorf("duplicate priority class: %s", name)
+		}
+
+		names[name] = true
+	}
+
+	if len(names) > 0 && !names[cfg.DefaultPriorityClass] {
+		return fmt.Errorf("unknown priority class: %s", cfg.DefaultPriorityClass)
+	}
+
+	return nil
+}
+
+func (cfg *Config) priorityClass(name string) *PriorityClass {
+	for i := range cfg.PriorityClasses {
+		if cfg.PriorityClasses[i].Name == name {
+			return &cfg.PriorityClasses[i]
+		}
+	}
+
+	return nil
+}
+
+// choosePriorityClass returns the class of finetune. The requested
+// class can't be higher than the one which the finetune is in by default.
+func (cfg *Config) choosePriorityClass(requested, user, task string) (*PriorityClass, error) {
+	if len(cfg.PriorityClasses) == 0 {
+		return nil, nil
+	}
+
+	c := cfg.priorityClass(cfg.DefaultPriorityClass)
+	for i := range cfg.PriorityClasses {
+		if v := &cfg.PriorityClasses[i]; v.Value > c.Value && v.match(user, task) {
+			c = v
+		}
+	}
+
+	if requested == "" {
+		return c, nil
+	}
+
+	v := cfg.priorityClass(requested)
+	if v == nil {
+		return nil, fmt.Errorf("unknown priority class: %s", requested)
+	}
+
+	if v.Value > c.Value {
+		return nil, fmt.Errorf("can't use the priority class: %s", requested)
+	}
+
+	return v, nil
+}
+
+// PriorityClass is the class of finetune for admission.
+type PriorityClass struct {
+	Name string `json:"name"`
+
+	// Value is the priority. The bigger, the higher.
+	Value int `json:"value"`
+
+	// AdmitRatio specifies the percent of max watch num which
+	// the finetunes of this class can use. It is 100 by default,
+	// and the lower class should have the smaller one so that the
+	// slots are reserved for the higher class.
+	AdmitRatio int `json:"admit_ratio"`
+
+	// Preemptible means the finetune of this class can be
+	// terminated for the finetune of higher class.
+	Preemptible bool `json:"preemptible"`
+
+	// Users and Tasks specify the finetunes which are in this class
+	// by default. The highest class is chosen if matching several.
+	Users []string `json:"users"`
+	Tasks []string `json:"tasks"`
+}
+
+func (c *PriorityClass) limit(maxWatchNum int) int {
+	return maxWatchNum * c.AdmitRatio / 100
+}
+
+func (c *PriorityClass) match(user, task string) bool {
+	for _, v := range c.Users {
+		if v == user {
+			return true
+		}
+	}
+
+	for _, v := range c.Tasks {
+		if v == task {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PollingConfig specifies the intervals of checking the finetune
+// by the phase of job. The unit is second.
+type PollingConfig struct {
+	// Fast is the interval when the job is just submitted,
+	// creating or terminating, so that it is found running
+	// or done quickly.
+	Fast int `json:"fast"`
+
+	// Slow is the interval when the job has been running for
+	// SlowAfter seconds, since it is unlikely to end soon.
+	Slow      int `json:"slow"`
+	SlowAfter int `json:"slow_after"`
+
+	// MaxBackoff is the max interval when the checks failed
+	// repeatedly. The interval doubles after each failure.
+	MaxBackoff int `json:"max_backoff"`
+}
+
+func (cfg *PollingConfig) setDefault(interval int) {
+	if cfg.Fast <= 0 {
+		cfg.Fast = 3
+	}
+
+	if cfg.Fast > interval {
+		cfg.Fast = interval
+	}
+
+	if cfg.Slow <= 0 {
+		cfg.Slow = 60
+		if cfg.Slow < interval {
+			cfg.Slow = interval
+		}
+	}
+
+	if cfg.SlowAfter <= 0 {
+		cfg.SlowAfter = 3600
+	}
+
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 300
+		if cfg.MaxBackoff < cfg.Slow {
+			cfg.MaxBackoff = cfg.Slow
+		}
+	}
+}
+
+func (cfg *PollingConfig) validate(interval int) error {
+	if cfg.Slow < interval {
+		return errors.New("the slow interval of polling should not be less than interval")
+	}
+
+	if cfg.MaxBackoff < cfg.Slow {
+		return errors.New("the max backoff of polling should not be less than the slow interval")
+	}
+
+	return nil
+}
+
+// CallbackConfig specifies how to retry the callback events
+// which failed to be delivered to the xihe server.
+type CallbackConfig struct {
+	// MaxAttempts is the num of attempts after which
+	// the event is dead and will not be delivered.
+	MaxAttempts int `json:"max_attempts"`
+
+	// MaxBackoff is the max interval between two attempts.
+	// The interval doubles after each attempt. The unit is second.
+	MaxBackoff int `json:"max_backoff"`
+}
+
+func (cfg *CallbackConfig) setDefault() {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 20
+	}
+
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 300
+	}
 }
@@ -0,0 +1,177 @@
+package watchimpl
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
+)
+
+// deliverInterval is the interval to deliver the callback events.
+const deliverInterval = time.Second
+
+// enqueue saves the status of finetune as a callback event to the outbox,
+// so it will be delivered to the xihe server even if the service restarts.
+func (w *Watcher) enqueue(info *finetuneInfo) error {
+	now := time.Now()
+	seq := now.UnixNano()
+
+	e := watch.CallbackEvent{
+		Id:            info.FinetuneId + "-" + strconv.FormatInt(seq, 10),
+		FinetuneId:    info.FinetuneId,
+		User:          info.User.Account(),
+		Model:         info.modelName(),
+		Seq:           seq,
+		Status:        info.result.Status,
+		Duration:      info.result.Duration,
+		LogPath:       info.result.LogPath,
+		OutputZipPath: info.result.OutputZipPath,
+		Final:         info.isDone(),
+		CreatedAt:     now.Unix(),
+	}
+
+	if p := info.detail.Progress; !p.IsEmpty() && !e.Final {
+		e.Progress = &p
+	}
+
+	return w.outbox.Save(&e)
+}
+
+// runOutbox delivers the callback events periodically if leading.
+func (w *Watcher) runOutbox() {
+	defer w.workers.Done()
+
+	ticker := time.NewTicker(deliverInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if w.isLeading() {
+				w.deliver()
+			}
+
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// deliver sends the due events of each finetune in order. The later events
+// of a finetune wait until the earlier one is delivered or dead.
+func (w *Watcher) deliver() {
+	events, err := w.outbox.FindAll()
+	if err != nil {
+		w.log.Errorf("find callback events failed, err:%s", err.Error())
+
+		return
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Seq < events[j].Seq
+	})
+
+	now := time.Now().Unix()
+	blocked := map[string]bool{}
+
+	for i := range events {
+		e := &events[i]
+
+		if e.Dead || blocked[e.FinetuneId] {
+			continue
+		}
+
+		switch {
+		case e.NextAttemptAt > now:
+			blocked[e.FinetuneId] = true
+
+		case w.send(e):
+			w.supersede(events[:i], e)
+
+		// the later events can be delivered if this one is dead,
+		// but it supersedes nothing since it is not delivered.
+		case !e.Dead:
+			blocked[e.FinetuneId] = true
+		}
+	}
+}
+
+// supersede marks the earlier dead events of the finetune of e which
+// has been delivered, so they will not be replayed.
+func (w *Watcher) supersede(earlier []watch.CallbackEvent, e *watch.CallbackEvent) {
+	for i := range earlier {
+		v := &earlier[i]
+
+		if v.FinetuneId != e.FinetuneId || !v.Dead || v.Superseded {
+			continue
+		}
+
+		v.Superseded = true
+
+		if err := w.outbox.Save(v); err != nil {
+			w.log.Errorf("save callback event(%s) failed, err:%s", v.Id, err.Error())
+		}
+	}
+}
+
+// send delivers the event and returns true if it is delivered. The event
+// is marked as dead if it failed too many times.
+func (w *Watcher) send(e *watch.CallbackEvent) bool {
+	status := watch.FinetuneStatus{
+		FinetuneId:    e.FinetuneId,
+		User:          e.User,
+		Model:         e.Model,
+		Status:        e.Status,
+		Duration:      e.Duration,
+		LogPath:       e.LogPath,
+		OutputZipPath: e.OutputZipPath,
+	}
+
+	if p := e.Progress; p != nil {
+		v := watch.ProgressStatus(*p)
+		status.Progress = &v
+	}
+
+	err := w.sink.Report(&status)
+	if err == nil {
+		if err := w.outbox.Remove(e.Id); err != nil {
+			w.log.Errorf("remove callback event(%s) failed, err:%s", e.Id, err.Error())
+		}
+
+		return true
+	}
+
+	w.log.Errorf("report the status of finetune(%s) failed, err:%s", e.FinetuneId, err.Error())
+
+	cfg := &w.cfg.Callback
+
+	e.Attempts++
+	e.LastError = err.Error()
+	e.Dead = e.Attempts >= cfg.MaxAttempts
+	e.NextAttemptAt = time.Now().Add(backoff(e.Attempts, seconds(cfg.MaxBackoff))).Unix()
+
+	if e.Dead {
+		w.log.Errorf("callback event(%s) is dead after %d attempts", e.Id, e.Attempts)
+	}
+
+	if err := w.outbox.Save(e); err != nil {
+		w.log.Errorf("save callback event(%s) failed, err:%s", e.Id, err.Error())
+	}
+
+	return false
+}
+
+// backoff returns the interval which doubles after each attempt.
+func backoff(attempts int, max time.Duration) time.Duration {
+	v := time.Second
+	for i := 1; i < attempts && v < max; i++ {
+		v *= 2
+	}
+
+	if v > max {
+		v = max
+	}
+
+	return v
+}
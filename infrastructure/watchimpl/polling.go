@@ -0,0 +1,41 @@
+package watchimpl
+
+import (
+	"time"
+
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
+)
+
+// pollInterval returns the interval before the next check of finetune
+// by the phase of its job. It backs off if the checks failed repeatedly.
+func (w *Watcher) pollInterval(info *finetuneInfo) time.Duration {
+	cfg := &w.cfg.Polling
+	v := w.interval
+
+	if !info.done {
+		switch info.detail.Status {
+		case nil, domain.TrainingStatusCreating, domain.TrainingStatusTerminating:
+			v = seconds(cfg.Fast)
+
+		case domain.TrainingStatusRunning:
+			if info.detail.Duration >= cfg.SlowAfter {
+				v = seconds(cfg.Slow)
+			}
+		}
+	}
+
+	max := seconds(cfg.MaxBackoff)
+	for i := 0; i < info.failures && v < max; i++ {
+		v *= 2
+	}
+
+	if v > max {
+		v = max
+	}
+
+	return v
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
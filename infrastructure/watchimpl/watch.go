@@ -7,92 +7,249 @@ import (
 	"time"
 
 	pt "github.com/opensourceways/xihe-grpc-protocol/grpc/aiccfinetune"
-
-	"github.com/opensourceways/xihe-grpc-protocol/grpc/client"
 	"github.com/sirupsen/logrus"
 
+	"github.com/opensourceways/xihe-aicc-finetune/domain"
 	"github.com/opensourceways/xihe-aicc-finetune/domain/aiccfinetune"
+	"github.com/opensourceways/xihe-aicc-finetune/domain/repository"
 	"github.com/opensourceways/xihe-aicc-finetune/domain/watch"
 )
 
 type aiccFinetuneData = pt.AICCFinetuneInfo
 
+// pickupInterval is the interval to watch the finetunes
+// handed off by the other replicas.
+const pickupInterval = 10 * time.Second
+
+// admissionGrace is the time after which the finetune admitted but
+// not watched is released. It should be longer than creating a job.
+const admissionGrace = 5 * time.Minute
+
+// maxPostProcessingAttempts is the num of times a step of post
+// processing is retried on the transient errors, such as the
+// errors of obs, before it is given up.
+const maxPostProcessingAttempts = 10
+
+// dispatchInterval is the interval to find the finetunes due to check.
+const dispatchInterval = time.Second
+
 func NewWatcher(
-	cfg *Config, as aiccfinetune.AICCFinetune,
+	cfg *Config,
+	as aiccfinetune.AICCFinetune,
+	sink watch.StatusSink,
+	repo repository.AICCFinetune,
+	states repository.WatchState,
+	outbox repository.CallbackOutbox,
+	ledger repository.AdmissionLedger,
+	log *logrus.Entry,
 ) (*Watcher, error) {
-	cli, err := client.NewAICCFinetuneClient(cfg.Endpoint)
-	if err != nil {
-		return nil, err
+	// the preempted finetunes are watched until they are terminated,
+	// so the num of finetunes may exceed the max watch num.
+	size := cfg.MaxWatchNum
+	if cfg.Preemption {
+		size += cfg.MaxWatchNum
 	}
 
 	return &Watcher{
-		cli:         cli,
-		as:          as,
-		timeout:     cfg.Timeout,
-		interval:    time.Duration(cfg.Interval) * time.Second,
-		stop:        make(chan struct{}),
-		stopped:     make(chan struct{}),
-		finetunes:   make(chan finetuneInfo, cfg.MaxWatchNum+1),
-		maxWatchNum: cfg.MaxWatchNum,
+		log:      log,
+		sink:     sink,
+		as:       as,
+		repo:     repo,
+		states:   states,
+		outbox:   outbox,
+		ledger:   ledger,
+		cfg:      cfg,
+		timeout:  cfg.Timeout,
+		pending:  int64(cfg.PendingThreshold),
+		interval: time.Duration(cfg.Interval) * time.Second,
+
+		shutdownTimeout: time.Duration(cfg.ShutdownTimeout) * time.Second,
+		stop:            make(chan struct{}),
+		stopped:         make(chan struct{}),
+		checks:          make(chan *finetuneInfo, size),
+		outputs:         make(chan *finetuneInfo, size),
+		jobs:            make(map[string]*finetuneInfo),
+		admitted:        make(map[string]*admission),
+		maxWatchNum:     cfg.MaxWatchNum,
 	}, nil
 }
 
+// admission is the finetune being watched.
+type admission struct {
+	class *PriorityClass
+
+	// preemptedBy is the id of finetune for
+	// which this one should be terminated.
+	preemptedBy string
+}
+
+func (a *admission) value() int {
+	if a.class == nil {
+		return 0
+	}
+
+	return a.class.Value
+}
+
 type finetuneInfo struct {
 	watch.FinetuneInfo
 
 	result aiccFinetuneData
+	detail domain.JobDetail
+
+	// pendingSince is the unix time since when the job
+	// is counted as pending for resubmitting.
+	pendingSince int64
+
+	// queuedSince is the unix time when the finetune is
+	// submitted at first. It is not reset by resubmitting.
+	queuedSince int64
+
+	done        bool
+	success     bool
+	logDone     bool
+	outputDone  bool
+	metricsDone bool
+	imagesDone  bool
+
+	// metricsAttempts and imagesAttempts are the num of times
+	// the post processing failed, see maxPostProcessingAttempts.
+	metricsAttempts int
+	imagesAttempts  int
+
+	// nextCheck is the time when the finetune will be checked.
+	nextCheck time.Time
+
+	// progressAt is the time when the progress was read last time.
+	progressAt time.Time
 
-	done       bool
-	success    bool
-	logDone    bool
-	outputDone bool
+	// failed means some check failed when handling the finetune this
+	// time, and failures is the num of times failed successively.
+	failed   bool
+	failures int
+
+	// busy means the finetune is being checked or post-processed
+	// by a worker, and it can't be dispatched again.
+	busy bool
+
+	// handedOff means the state has been saved by Exit, so the worker
+	// must not report it which will be done again after restored.
+	handedOff bool
+
+	// state is the snapshot after the finetune was handled last time.
+	// It will be saved when the watcher exits.
+	state watch.WatchState
 }
 
-func (t *finetuneInfo) toIndex() pt.AICCFinetuneIndex {
-	return pt.AICCFinetuneIndex{
-		Id:    t.FinetuneId,
-		User:  t.User.Account(),
-		Model: "wukong",
+func (t *finetuneInfo) toState(class string) watch.WatchState {
+	return watch.WatchState{
+		FinetuneInfo:  t.FinetuneInfo,
+		PriorityClass: class,
+		PendingSince:  t.pendingSince,
+		QueuedSince:   t.queuedSince,
+		Done:          t.done,
+		Success:       t.success,
+		LogDone:       t.logDone,
+		OutputDone:    t.outputDone,
+		MetricsDone:   t.metricsDone,
+		ImagesDone:    t.imagesDone,
 	}
 }
 
+func (t *finetuneInfo) modelName() string {
+	if t.Model == nil {
+		return ""
+	}
+
+	return t.Model.ModelName()
+}
+
 func (t *finetuneInfo) isDone() bool {
 	done := t.done && t.logDone
 
 	if done && t.success {
-		done = t.outputDone
+		done = t.outputDone && t.metricsDone && t.imagesDone
 	}
 
 	return done
 }
 
+// needPostProcessing returns true if the job completed successfully
+// but its output has not been processed.
+func (t *finetuneInfo) needPostProcessing() bool {
+	return t.done && t.success && !(t.outputDone && t.metricsDone && t.imagesDone)
+}
+
 // Watcher
 type Watcher struct {
-	log *logrus.Entry
-	cli *client.AICCFinetuneClient
-	as  aiccfinetune.AICCFinetune
+	log  *logrus.Entry
+	sink watch.StatusSink
+	as   aiccfinetune.AICCFinetune
+	repo repository.AICCFinetune
+	cfg  *Config
+
+	states repository.WatchState
+	outbox repository.CallbackOutbox
+	ledger repository.AdmissionLedger
+
+	handlers []watch.DoneHandler
 
 	timeout  int
+	pending  int64
 	interval time.Duration
 
-	stop      chan struct{}
-	stopped   chan struct{}
-	finetunes chan finetuneInfo
+	shutdownTimeout time.Duration
+
+	stop    chan struct{}
+	stopped chan struct{}
+	workers sync.WaitGroup
+
+	// checks is the queue of finetunes to check the status.
+	checks chan *finetuneInfo
+	// outputs is the queue of finetunes to process the output.
+	outputs chan *finetuneInfo
 
+	jobsLock sync.Mutex
+	jobs     map[string]*finetuneInfo
+	// closing means the watcher is exiting, and the
+	// finetunes applying for watching will be handed off.
+	closing bool
+	// leading means the watcher watches the finetunes itself.
+	// Otherwise, it hands off them to the leader replica.
+	leading bool
+
+	// lock guards admitted which are the finetunes watched by this
+	// replica. The num of finetunes is counted by the ledger which
+	// includes the ones admitted by the other replicas.
 	lock        sync.RWMutex
-	currentNum  int
 	maxWatchNum int
+	admitted    map[string]*admission
 }
 
-func (w *Watcher) ApplyWatch(f func(*watch.FinetuneInfo) error) (err error) {
-	if !w.increase() {
-		return errors.New("exceed max watch num")
+func (w *Watcher) ApplyWatch(a *watch.Admission, f func(*watch.FinetuneInfo) error) (err error) {
+	if w.isClosing() {
+		return errors.New("the watcher is shutting down")
+	}
+
+	class, err := w.cfg.choosePriorityClass(
+		a.PriorityClass, a.User.Account(), a.Task.TaskType(),
+	)
+	if err != nil {
+		return
+	}
+
+	if !w.isLeading() {
+		return w.handOver(a.FinetuneId, class, f)
+	}
+
+	if !w.increase(a.FinetuneId, class) {
+		return watch.NewErrorExceedMaxWatchNum()
 	}
 
 	info := new(watch.FinetuneInfo)
 
 	if err = f(info); err != nil {
-		w.decrease()
+		w.decrease(a.FinetuneId)
 	} else {
 		w.addFinetune(info)
 	}
@@ -100,79 +257,431 @@ func (w *Watcher) ApplyWatch(f func(*watch.FinetuneInfo) error) (err error) {
 	return
 }
 
+// handOver creates the job and saves its state for the leader to watch.
+// It is admitted by the ledger shared with the leader, but it can't
+// preempt the others which are only known by the leader.
+func (w *Watcher) handOver(
+	finetuneId string, class *PriorityClass, f func(*watch.FinetuneInfo) error,
+) error {
+	limit := w.limit(class)
+
+	ok, err := w.ledger.Admit(
+		finetuneId, w.admissionRecord(class),
+		func(admitted map[string]watch.AdmissionRecord) bool {
+			return len(admitted)+1 <= limit
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return watch.NewErrorExceedMaxWatchNum()
+	}
+
+	t := new(watch.FinetuneInfo)
+	if err := f(t); err != nil {
+		w.release(finetuneId)
+
+		return err
+	}
+
+	info := &finetuneInfo{
+		FinetuneInfo: *t,
+		pendingSince: t.CreatedAt,
+		queuedSince:  t.CreatedAt,
+	}
+
+	name := ""
+	if class != nil {
+		name = class.Name
+	}
+
+	info.state = info.toState(name)
+
+	w.saveState(info)
+
+	return nil
+}
+
+func (w *Watcher) isClosing() bool {
+	w.jobsLock.Lock()
+	defer w.jobsLock.Unlock()
+
+	return w.closing
+}
+
+func (w *Watcher) isLeading() bool {
+	w.jobsLock.Lock()
+	defer w.jobsLock.Unlock()
+
+	return w.leading
+}
+
+// Lead makes the watcher watch the finetunes itself, including the ones
+// handed off by the other replicas or the last exit. Before it, the
+// finetunes applying for watching are handed off to the leader.
+func (w *Watcher) Lead() {
+	w.jobsLock.Lock()
+	w.leading = true
+	w.jobsLock.Unlock()
+
+	if err := w.restore(); err != nil {
+		w.log.Errorf("restore watch state failed, err:%s", err.Error())
+	}
+}
+
 func (w *Watcher) addFinetune(t *watch.FinetuneInfo) {
-	info := finetuneInfo{FinetuneInfo: *t}
-	w.finetunes <- info
+	info := &finetuneInfo{
+		FinetuneInfo: *t,
+		pendingSince: t.CreatedAt,
+		queuedSince:  t.CreatedAt,
+		nextCheck:    time.Now(),
+	}
+
+	w.jobsLock.Lock()
+	defer w.jobsLock.Unlock()
+
+	info.state = info.toState(w.priorityClassName(t.FinetuneId))
+
+	if w.closing {
+		// the job has been created, so hand it off to the next start.
+		w.saveState(info)
+
+		return
+	}
+
+	w.jobs[t.FinetuneId] = info
 }
 
-func (w *Watcher) increase() (b bool) {
-	w.lock.Lock()
-	if w.currentNum+1 <= w.maxWatchNum {
-		w.currentNum++
-		b = true
+// restore watches the finetunes handed off by the other replicas
+// or when the watcher exited last time.
+func (w *Watcher) restore() error {
+	states, err := w.states.FindAll()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	n := 0
+
+	for i := range states {
+		s := &states[i]
+
+		if w.isClosing() {
+			break
+		}
+
+		if w.Watching(s.FinetuneId) {
+			w.removeState(s.FinetuneId)
+
+			continue
+		}
+
+		info := &finetuneInfo{
+			FinetuneInfo: s.FinetuneInfo,
+			pendingSince: s.PendingSince,
+			queuedSince:  s.QueuedSince,
+			done:         s.Done,
+			success:      s.Success,
+			logDone:      s.LogDone,
+			outputDone:   s.OutputDone,
+			metricsDone:  s.MetricsDone,
+			imagesDone:   s.ImagesDone,
+			nextCheck:    now,
+			state:        *s,
+		}
+
+		if t, err := w.repo.Get(s.FinetuneId); err == nil {
+			info.Model = t.Model
+			info.detail = t.JobDetail
+			info.result = aiccFinetuneData{
+				Duration:      t.JobDetail.Duration,
+				LogPath:       t.JobDetail.LogPath,
+				OutputZipPath: t.JobDetail.OutputPath,
+			}
+
+			if v := t.JobDetail.Status; v != nil {
+				info.result.Status = v.TrainingStatus()
+			}
+		}
+
+		class := w.cfg.priorityClass(s.PriorityClass)
+
+		// it has been admitted when handed off, but the
+		// ledger may not have it if handed off by old version.
+		_, err := w.ledger.Admit(
+			s.FinetuneId, w.admissionRecord(class),
+			func(map[string]watch.AdmissionRecord) bool { return true },
+		)
+		if err != nil {
+			w.log.Errorf(
+				"admit finetune(%s) failed, err:%s", s.FinetuneId, err.Error(),
+			)
+		}
+
+		w.lock.Lock()
+		w.admitted[s.FinetuneId] = &admission{class: class}
+		w.lock.Unlock()
+
+		w.jobsLock.Lock()
+		w.jobs[s.FinetuneId] = info
+		w.jobsLock.Unlock()
+
+		w.removeState(s.FinetuneId)
+		n++
+	}
+
+	if n > 0 {
+		w.log.Infof("restore %d finetunes to watch", n)
+	}
+
+	return nil
+}
+
+func (w *Watcher) removeState(finetuneId string) {
+	if err := w.states.Remove(finetuneId); err != nil {
+		w.log.Errorf(
+			"remove the watch state of finetune(%s) failed, err:%s",
+			finetuneId, err.Error(),
+		)
+	}
+}
+
+func (w *Watcher) priorityClassName(finetuneId string) string {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if v, ok := w.admitted[finetuneId]; ok && v.class != nil {
+		return v.class.Name
+	}
+
+	return ""
+}
+
+func (w *Watcher) saveState(info *finetuneInfo) {
+	if err := w.states.Save(&info.state); err != nil {
+		w.log.Errorf(
+			"save the watch state of finetune(%s) failed, err:%s",
+			info.FinetuneId, err.Error(),
+		)
+	}
+}
+
+// increase admits the finetune if the num of finetunes is under the limit
+// of its class, or it can preempt a finetune of lower class.
+func (w *Watcher) increase(finetuneId string, class *PriorityClass) bool {
+	limit := w.limit(class)
+
+	ok, err := w.ledger.Admit(
+		finetuneId, w.admissionRecord(class),
+		func(admitted map[string]watch.AdmissionRecord) bool {
+			if len(admitted)+1 <= limit {
+				return true
+			}
+
+			w.lock.Lock()
+			defer w.lock.Unlock()
+
+			victim := w.chooseVictim(class)
+			if victim == "" {
+				return false
+			}
+
+			w.admitted[victim].preemptedBy = finetuneId
+
+			w.log.Infof(
+				"finetune(%s) will be preempted by finetune(%s)", victim, finetuneId,
+			)
+
+			return true
+		},
+	)
+	if err != nil {
+		w.log.Errorf("admit finetune(%s) failed, err:%s", finetuneId, err.Error())
+
+		return false
+	}
+
+	if ok {
+		w.lock.Lock()
+		w.admitted[finetuneId] = &admission{class: class}
+		w.lock.Unlock()
+	}
+
+	return ok
+}
+
+func (w *Watcher) limit(class *PriorityClass) int {
+	if class == nil {
+		return w.maxWatchNum
+	}
+
+	return class.limit(w.maxWatchNum)
+}
+
+func (w *Watcher) admissionRecord(class *PriorityClass) *watch.AdmissionRecord {
+	r := &watch.AdmissionRecord{AdmittedAt: time.Now().Unix()}
+	if class != nil {
+		r.PriorityClass = class.Name
+	}
+
+	return r
+}
+
+// chooseVictim returns the preemptible finetune of the lowest class
+// which is lower than the specified one.
+func (w *Watcher) chooseVictim(class *PriorityClass) (r string) {
+	if !w.cfg.Preemption || class == nil {
+		return
+	}
+
+	var victim *admission
+
+	for id, v := range w.admitted {
+		if v.class == nil || !v.class.Preemptible || v.preemptedBy != "" {
+			continue
+		}
+
+		if v.value() >= class.Value {
+			continue
+		}
+
+		if victim == nil || v.value() < victim.value() {
+			r = id
+			victim = v
+		}
 	}
-	w.lock.Unlock()
 
 	return
 }
 
-func (w *Watcher) decrease() {
+// preemptedBy returns the id of finetune which preempts the one.
+func (w *Watcher) preemptedBy(finetuneId string) string {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if v, ok := w.admitted[finetuneId]; ok {
+		return v.preemptedBy
+	}
+
+	return ""
+}
+
+// Available returns the num of finetunes which can be watched more
+// by all the replicas.
+func (w *Watcher) Available() int {
+	admitted, err := w.ledger.FindAll()
+	if err != nil {
+		w.log.Errorf("find the admitted finetunes failed, err:%s", err.Error())
+
+		return 0
+	}
+
+	return w.maxWatchNum - len(admitted)
+}
+
+func (w *Watcher) Watching(finetuneId string) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	_, ok := w.admitted[finetuneId]
+
+	return ok
+}
+
+func (w *Watcher) decrease(finetuneId string) {
 	w.lock.Lock()
-	w.currentNum--
+	delete(w.admitted, finetuneId)
 	w.lock.Unlock()
+
+	w.release(finetuneId)
+}
+
+func (w *Watcher) release(finetuneId string) {
+	if err := w.ledger.Release(finetuneId); err != nil {
+		w.log.Errorf("release finetune(%s) failed, err:%s", finetuneId, err.Error())
+	}
+}
+
+// prune releases the finetunes which were admitted a while ago, but are
+// neither watched nor handed off, such as the replica crashed before
+// creating the job. It should be called by the leader.
+func (w *Watcher) prune() {
+	admitted, err := w.ledger.FindAll()
+	if err != nil {
+		w.log.Errorf("find the admitted finetunes failed, err:%s", err.Error())
+
+		return
+	}
+
+	states, err := w.states.FindAll()
+	if err != nil {
+		w.log.Errorf("find watch states failed, err:%s", err.Error())
+
+		return
+	}
+
+	handedOff := make(map[string]bool, len(states))
+	for i := range states {
+		handedOff[states[i].FinetuneId] = true
+	}
+
+	for id, r := range admitted {
+		if handedOff[id] || w.Watching(id) || time.Since(time.Unix(r.AdmittedAt, 0)) < admissionGrace {
+			continue
+		}
+
+		w.log.Warnf("release finetune(%s) which is admitted but not watched", id)
+
+		w.release(id)
+	}
 }
 
+// Run dispatches the finetunes which are due to check to the workers.
+// The status of finetunes are checked concurrently by a pool of workers,
+// and the outputs are processed by another pool, so a slow job will not
+// delay checking the others. If leading, it also picks up the finetunes
+// handed off by the other replicas periodically.
 func (w *Watcher) Run() {
-	start := time.Now()
+	for i := 0; i < w.cfg.Workers; i++ {
+		w.workers.Add(1)
+		go w.work(w.checks, w.handleStatus)
+	}
 
-	// add the tag
-	w.finetunes <- finetuneInfo{}
+	for i := 0; i < w.cfg.OutputWorkers; i++ {
+		w.workers.Add(1)
+		go w.work(w.outputs, w.handleOutput)
+	}
 
-	for {
-		select {
-		case info := <-w.finetunes:
-			// use =="" stands for the case that the loop is done
-			if info.User == nil {
-				w.log.Debug("finish a loop")
+	w.workers.Add(1)
+	go w.runOutbox()
 
-				t := start.Add(w.interval)
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
 
-				if n := time.Now(); t.After(n) {
-					time.Sleep(t.Sub(n))
-				}
+	pickup := time.NewTicker(pickupInterval)
+	defer pickup.Stop()
 
-				w.finetunes <- finetuneInfo{}
+	for {
+		select {
+		case <-ticker.C:
+			w.dispatch()
 
-				start = time.Now()
+		case <-pickup.C:
+			if !w.isLeading() {
+				break
+			}
 
-			} else {
-				changed := w.check(&info)
-				fmt.Printf("aicc info: %+v\n", info)
-				w.log.Debugf("check aicc finetune %s/%s", info.FinetuneId, info.JobId)
-				if info.isDone() {
-					index := info.toIndex()
-
-					if err := w.cli.SetAICCFinetuneInfo(&index, &info.result); err == nil {
-						w.decrease()
-					} else {
-						w.log.Errorf("set aicc finetune info failed, err:%s", err.Error())
-						w.finetunes <- info
-					}
-
-				} else {
-					if changed {
-						fmt.Printf("aicc info: %+v\n", info)
-						index := info.toIndex()
-						if err := w.cli.SetAICCFinetuneInfo(&index, &info.result); err != nil {
-							w.log.Errorf("set aicc finetune info failed, err:%s", err.Error())
-						}
-					}
-
-					w.finetunes <- info
-				}
+			if err := w.restore(); err != nil {
+				w.log.Errorf("restore watch state failed, err:%s", err.Error())
 			}
 
+			w.prune()
+
 		case <-w.stop:
+			w.workers.Wait()
+
 			close(w.stopped)
 
 			return
@@ -180,22 +689,376 @@ func (w *Watcher) Run() {
 	}
 }
 
+func (w *Watcher) dispatch() {
+	now := time.Now()
+
+	w.jobsLock.Lock()
+	defer w.jobsLock.Unlock()
+
+	for _, info := range w.jobs {
+		if info.busy || info.nextCheck.After(now) {
+			continue
+		}
+
+		select {
+		case w.checks <- info:
+			info.busy = true
+		default:
+			// all the workers are busy, try it next time.
+			return
+		}
+	}
+}
+
+func (w *Watcher) work(queue chan *finetuneInfo, handle func(*finetuneInfo)) {
+	defer w.workers.Done()
+
+	for {
+		select {
+		case info := <-queue:
+			handle(info)
+
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *Watcher) handleStatus(info *finetuneInfo) {
+	changed := w.checkStatus(info)
+
+	w.log.Debugf("check aicc finetune %s/%s", info.FinetuneId, info.JobId)
+
+	if changed {
+		w.saveDetail(info)
+	}
+
+	if !info.needPostProcessing() {
+		w.finish(info, changed)
+
+		return
+	}
+
+	if changed {
+		w.reportUnlessHandedOff(info, false)
+	}
+
+	// it never blocks, because the queue can hold all the finetunes.
+	w.outputs <- info
+}
+
+func (w *Watcher) handleOutput(info *finetuneInfo) {
+	changed := w.postProcess(info)
+
+	if changed {
+		w.saveDetail(info)
+	}
+
+	w.finish(info, changed)
+}
+
+// finish reports the finetune and stops watching it if it is done.
+// Otherwise, it will be checked after the interval of its phase.
+func (w *Watcher) finish(info *finetuneInfo, changed bool) {
+	if info.isDone() {
+		if w.reportUnlessHandedOff(info, true) {
+			w.decrease(info.FinetuneId)
+			w.notifyDone(info.FinetuneId)
+
+			return
+		}
+	} else if changed {
+		w.reportUnlessHandedOff(info, false)
+	}
+
+	if info.failed {
+		info.failures++
+	} else {
+		info.failures = 0
+	}
+	info.failed = false
+
+	w.jobsLock.Lock()
+	info.busy = false
+	info.nextCheck = time.Now().Add(w.pollInterval(info))
+	info.state = info.toState(w.priorityClassName(info.FinetuneId))
+	w.jobsLock.Unlock()
+}
+
+// reportUnlessHandedOff reports the finetune unless Exit has handed it off
+// after timed out waiting for the worker. The finetune stops being watched
+// if the final status is reported, so it will not be handed off then.
+func (w *Watcher) reportUnlessHandedOff(info *finetuneInfo, final bool) bool {
+	w.jobsLock.Lock()
+	defer w.jobsLock.Unlock()
+
+	if info.handedOff || w.report(info) != nil {
+		return false
+	}
+
+	if final {
+		delete(w.jobs, info.FinetuneId)
+	}
+
+	return true
+}
+
+// report puts the status of finetune to the outbox which delivers it
+// to the xihe server in order and retries it on failure.
+func (w *Watcher) report(info *finetuneInfo) error {
+	err := w.enqueue(info)
+	if err != nil {
+		w.log.Errorf(
+			"add callback event of finetune(%s) failed, err:%s",
+			info.FinetuneId, err.Error(),
+		)
+	}
+
+	return err
+}
+
+// RegisterDoneHandler registers the handler which is called after
+// the finetune is done. It should be called before Run.
+func (w *Watcher) RegisterDoneHandler(h watch.DoneHandler) {
+	w.handlers = append(w.handlers, h)
+}
+
+// notifyDone calls the handlers asynchronously, because they
+// may apply watching the new finetunes.
+func (w *Watcher) notifyDone(finetuneId string) {
+	for _, h := range w.handlers {
+		go h(finetuneId)
+	}
+}
+
+// Exit stops accepting new finetunes and waits for the workers to finish
+// the current work until the shutdown timeout. The finetunes being watched
+// are saved and will be restored at the next start.
 func (w *Watcher) Exit() {
+	w.jobsLock.Lock()
+	w.closing = true
+	w.jobsLock.Unlock()
+
 	close(w.stop)
 
-	<-w.stopped
+	select {
+	case <-w.stopped:
+	case <-time.After(w.shutdownTimeout):
+		w.log.Warn("timeout to wait for the workers of watcher")
+	}
+
+	w.handOff()
+
+	if err := w.sink.Close(); err != nil {
+		w.log.Errorf("close status sink failed, err:%s", err.Error())
+	}
+}
+
+// handOff saves the state of finetunes being watched. The work of
+// busy finetunes is interrupted and will be done again after restored.
+func (w *Watcher) handOff() {
+	w.jobsLock.Lock()
+	defer w.jobsLock.Unlock()
+
+	ids := make([]string, 0, len(w.jobs))
+	interrupted := []string{}
 
-	w.cli.Disconnect()
+	for id, info := range w.jobs {
+		w.saveState(info)
+		info.handedOff = true
+
+		ids = append(ids, id)
+		if info.busy {
+			interrupted = append(interrupted, id)
+		}
+	}
+
+	w.log.Infof(
+		"watcher exits and hands off %d finetunes: %v, and the work of %v is interrupted",
+		len(ids), ids, interrupted,
+	)
+}
+
+func (w *Watcher) saveDetail(info *finetuneInfo) {
+	if err := w.repo.UpdateDetail(info.FinetuneId, &info.detail); err != nil {
+		w.log.Errorf(
+			"save detail of finetune(%s) failed, err:%s",
+			info.FinetuneId, err.Error(),
+		)
+	}
 }
 
-func (w *Watcher) check(info *finetuneInfo) (changed bool) {
+// terminationReason returns the status and reason if the unfinished
+// job should be terminated because it is preempted or timed out.
+func (w *Watcher) terminationReason(info *finetuneInfo, detail *domain.JobDetail) (
+	domain.TrainingStatus, string,
+) {
+	if v := w.preemptedBy(info.FinetuneId); v != "" {
+		return domain.TrainingStatusTerminated, fmt.Sprintf("preempted by finetune(%s)", v)
+	}
+
+	return domain.TrainingStatusTimeout, w.timeoutReason(info, detail)
+}
+
+// timeoutReason returns the reason if the job exceeds its time limits.
+// The queue time is checked before running and the run time is checked
+// during running. The timeout of config is used if the job has no limit.
+func (w *Watcher) timeoutReason(info *finetuneInfo, detail *domain.JobDetail) string {
+	switch detail.Status {
+	case domain.TrainingStatusPending, domain.TrainingStatusCreating:
+		q := info.MaxQueueTime
+		if q > 0 && time.Now().Unix()-info.queuedSince >= int64(q) {
+			return fmt.Sprintf("exceeded the max queue time of %ds", q)
+		}
+
+	case domain.TrainingStatusRunning:
+		r := info.MaxRunTime
+		if r <= 0 {
+			r = w.timeout
+		}
+
+		if detail.Duration >= r {
+			return fmt.Sprintf("exceeded the max run time of %ds", r)
+		}
+	}
+
+	return ""
+}
+
+// retry resubmits the finished job as a new attempt if the retry
+// policy of finetune allows. It returns true if resubmitted.
+func (w *Watcher) retry(info *finetuneInfo, detail *domain.JobDetail) bool {
+	if detail.Status.IsSuccess() {
+		return false
+	}
+
+	t, err := w.repo.Get(info.FinetuneId)
+	if err != nil {
+		w.log.Errorf("get finetune(%s) failed, err:%s", info.FinetuneId, err.Error())
+
+		return false
+	}
+
+	if !t.Retry.ShouldRetry(detail.Status, len(info.detail.Attempts)) {
+		return false
+	}
+
+	v, err := w.as.Create(&t)
+	if err != nil {
+		w.log.Errorf(
+			"retry the job(%s) of finetune(%s) failed, err:%s",
+			info.JobId, info.FinetuneId, err.Error(),
+		)
+
+		return false
+	}
+
+	w.log.Infof(
+		"finetune(%s) is retried from job(%s) with status %s to job(%s)",
+		info.FinetuneId, info.JobId, detail.Status.TrainingStatus(), v.JobId,
+	)
+
+	info.detail.Attempts = append(info.detail.Attempts, domain.JobAttempt{
+		JobId:     info.JobId,
+		Pool:      info.Pool,
+		Status:    detail.Status,
+		Duration:  detail.Duration,
+		CreatedAt: info.CreatedAt,
+		StartedAt: detail.StartedAt,
+	})
+	info.detail.Status = domain.TrainingStatusCreating
+	info.detail.Duration = 0
+	info.detail.StartedAt = 0
+	info.detail.Progress = domain.Progress{}
+	info.detail.ProgressHistory = nil
+
+	info.JobInfo = v
+	info.pendingSince = v.CreatedAt
+	info.queuedSince = v.CreatedAt
+
+	if err := w.repo.UpdateJob(info.FinetuneId, &v); err != nil {
+		w.log.Errorf(
+			"save job of finetune(%s) failed, err:%s",
+			info.FinetuneId, err.Error(),
+		)
+	}
+
+	return true
+}
+
+// repool resubmits the job which has been pending too long to another pool.
+func (w *Watcher) repool(info *finetuneInfo) {
+	if w.pending <= 0 {
+		return
+	}
+
+	now := time.Now().Unix()
+	if now-info.pendingSince < w.pending {
+		return
+	}
+
+	info.pendingSince = now
+
+	t, err := w.repo.Get(info.FinetuneId)
+	if err != nil {
+		w.log.Errorf("get finetune(%s) failed, err:%s", info.FinetuneId, err.Error())
+
+		return
+	}
+
+	v, err := w.as.Resubmit(&t)
+	if err != nil {
+		w.log.Errorf(
+			"resubmit the pending job(%s) failed, err:%s",
+			info.JobId, err.Error(),
+		)
+
+		return
+	}
+
+	if err := w.as.Terminate(info.JobId); err != nil {
+		w.log.Errorf(
+			"terminate the pending job(%s) failed, err:%s",
+			info.JobId, err.Error(),
+		)
+	}
+
+	w.log.Infof(
+		"finetune(%s) is resubmitted from job(%s) on pool(%s) to job(%s) on pool(%s)",
+		info.FinetuneId, info.JobId, info.Pool, v.JobId, v.Pool,
+	)
+
+	info.JobInfo = v
+
+	if err := w.repo.UpdateJob(info.FinetuneId, &v); err != nil {
+		w.log.Errorf(
+			"save job of finetune(%s) failed, err:%s",
+			info.FinetuneId, err.Error(),
+		)
+	}
+}
+
+// checkStatus updates the status of job and fetches its log.
+func (w *Watcher) checkStatus(info *finetuneInfo) (changed bool) {
 	result := &info.result
 
 	if !info.done {
 		detail, err := w.as.GetDetail(info.JobId)
 		if err != nil {
+			w.log.Errorf("get detail of job(%s) failed, err:%s", info.JobId, err.Error())
+			info.failed = true
+
 			return
 		}
+
+		if detail.Status.IsDone() && w.retry(info, &detail) {
+			result.Status = info.detail.Status.TrainingStatus()
+			result.Duration = 0
+			changed = true
+
+			return
+		}
+
 		if detail.Duration != result.Duration {
 			result.Duration = detail.Duration
 			changed = true
@@ -206,8 +1069,21 @@ func (w *Watcher) check(info *finetuneInfo) (changed bool) {
 			changed = true
 		}
 
+		info.detail.Status = detail.Status
+		info.detail.Duration = detail.Duration
+		info.detail.StartedAt = detail.StartedAt
+
 		if !detail.Status.IsDone() {
-			if detail.Duration < w.timeout {
+			if detail.Status == domain.TrainingStatusPending {
+				w.repool(info)
+			}
+
+			if detail.Status == domain.TrainingStatusRunning && w.checkProgress(info) {
+				changed = true
+			}
+
+			status, reason := w.terminationReason(info, &detail)
+			if reason == "" {
 				return
 			}
 
@@ -216,14 +1092,19 @@ func (w *Watcher) check(info *finetuneInfo) (changed bool) {
 					"terminate the job(%s) failed, err:%s",
 					info.JobId, err.Error(),
 				)
+				info.failed = true
 
 				return
 			}
 
-			result.Status = "Timeout"
+			result.Status = status.TrainingStatus()
+			info.detail.Status = status
+			info.detail.TerminationReason = reason
 			changed = true
 		} else {
 			info.success = detail.Status.IsSuccess()
+			info.detail.Error = detail.Error
+			info.detail.ErrorCategory = detail.ErrorCategory
 		}
 		info.done = true
 	}
@@ -231,25 +1112,158 @@ func (w *Watcher) check(info *finetuneInfo) (changed bool) {
 	if !info.logDone {
 		if v, err := w.as.GetLogFilePath(info.LogDir); err != nil {
 			w.log.Errorf("generate log failed, err:%s", err.Error())
+			info.failed = true
 		} else {
 			result.LogPath = v
+			info.detail.LogPath = v
 			info.logDone = true
 			changed = true
+
+			w.diagnose(info)
 		}
 	}
 
-	if !info.success {
+	return
+}
+
+// checkProgress reads the training progress of the running job
+// at the progress interval. It returns true if the training advanced.
+func (w *Watcher) checkProgress(info *finetuneInfo) bool {
+	interval := w.cfg.ProgressInterval
+	if interval < 0 || time.Since(info.progressAt) < seconds(interval) {
+		return false
+	}
+
+	info.progressAt = time.Now()
+
+	p, err := w.as.GetProgress(info.OutputDir, info.LogDir)
+	if err != nil {
+		w.log.Errorf(
+			"get the progress of finetune(%s) failed, err:%s", info.FinetuneId, err.Error(),
+		)
+
+		return false
+	}
+
+	p.UpdatedAt = info.progressAt.Unix()
+
+	return info.detail.UpdateProgress(p)
+}
+
+// diagnose scans the end of log of the failed job for the known errors
+// which explain the failure better than the exit code of job.
+func (w *Watcher) diagnose(info *finetuneInfo) {
+	st := info.detail.Status
+	if info.detail.LogPath == "" ||
+		(st != domain.TrainingStatusFailed && st != domain.TrainingStatusAbnormal) {
+		return
+	}
+
+	tail, err := w.as.GetLogTail(info.LogDir)
+	if err != nil {
+		w.log.Errorf(
+			"get the log of finetune(%s) failed, err:%s", info.FinetuneId, err.Error(),
+		)
+
+		return
+	}
+
+	category, msg := domain.DiagnoseLog(tail)
+	if category == "" {
 		return
 	}
 
+	if info.detail.Error != "" {
+		msg += "; " + info.detail.Error
+	}
+
+	info.detail.Error = msg
+	info.detail.ErrorCategory = category
+}
+
+// giveUp records the failure of a step of post processing. The step is
+// given up if the error is not transient or it failed too many times,
+// and the error is recorded so the finetune can still be done.
+func (w *Watcher) giveUp(info *finetuneInfo, step string, attempts int, err error) bool {
+	w.log.Errorf(
+		"%s of finetune(%s) failed, attempts:%d, err:%s",
+		step, info.FinetuneId, attempts, err.Error(),
+	)
+
+	if !aiccfinetune.IsErrorInvalidFile(err) && attempts < maxPostProcessingAttempts {
+		info.failed = true
+
+		return false
+	}
+
+	msg := fmt.Sprintf("%s failed: %s", step, err.Error())
+	if info.detail.Error != "" {
+		msg = info.detail.Error + "; " + msg
+	}
+
+	info.detail.Error = msg
+
+	return true
+}
+
+// postProcess processes the output of job which completed successfully.
+func (w *Watcher) postProcess(info *finetuneInfo) (changed bool) {
+	result := &info.result
+
 	if !info.outputDone {
-		if v, err := w.as.GenOutput(info.OutputDir); err != nil {
+		if !info.Task.Kind().HasPostProcessing(domain.PostProcessingPackOutput) {
+			info.outputDone = true
+		} else if v, err := w.as.GenOutput(info.OutputDir); err != nil {
 			w.log.Errorf("generate output failed, err:%s", err.Error())
+			info.failed = true
 		} else {
 			info.outputDone = true
 
 			if v != "" {
 				result.OutputZipPath = v
+				info.detail.OutputPath = v
+				changed = true
+			}
+		}
+	}
+
+	if !info.metricsDone {
+		if !info.Task.Kind().HasPostProcessing(domain.PostProcessingParseMetrics) {
+			info.metricsDone = true
+		} else if v, err := w.as.GetMetrics(info.OutputDir); err != nil {
+			info.metricsAttempts++
+
+			if w.giveUp(info, "parse metrics", info.metricsAttempts, err) {
+				info.metricsDone = true
+				changed = true
+			}
+		} else {
+			info.metricsDone = true
+
+			if len(v) > 0 {
+				info.detail.Metrics = v
+				changed = true
+			} else {
+				w.log.Warnf("no metrics found for finetune(%s)", info.FinetuneId)
+			}
+		}
+	}
+
+	if !info.imagesDone {
+		if !info.Task.Kind().HasPostProcessing(domain.PostProcessingCollectImages) {
+			info.imagesDone = true
+		} else if v, err := w.as.CollectImages(info.OutputDir); err != nil {
+			info.imagesAttempts++
+
+			if w.giveUp(info, "collect images", info.imagesAttempts, err) {
+				info.imagesDone = true
+				changed = true
+			}
+		} else {
+			info.imagesDone = true
+
+			if len(v) > 0 {
+				info.detail.Images = v
 				changed = true
 			}
 		}
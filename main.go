@@ -3,12 +3,16 @@ package main
 import (
 	"flag"
 	"os"
+	"syscall"
+	"time"
 
 	"github.com/opensourceways/community-robot-lib/logrusutil"
 	liboptions "github.com/opensourceways/community-robot-lib/options"
 	"github.com/opensourceways/xihe-aicc-finetune/app"
 	"github.com/opensourceways/xihe-aicc-finetune/config"
 	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/aiccfinetuneimpl"
+	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/repositoryimpl"
+	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/statussinkimpl"
 	"github.com/opensourceways/xihe-aicc-finetune/infrastructure/watchimpl"
 	"github.com/opensourceways/xihe-aicc-finetune/server"
 	"github.com/sirupsen/logrus"
@@ -71,21 +75,129 @@ func main() {
 		logrus.Errorf("new finetune client failed, err:%s", err.Error())
 	}
 
+	// repository
+	repo, err := repositoryimpl.NewAICCFinetuneRepository(&cfg.Repository)
+	if err != nil {
+		logrus.Fatalf("new finetune repository failed, err:%s", err.Error())
+	}
+
+	sweepRepo, err := repositoryimpl.NewSweepRepository(&cfg.Repository)
+	if err != nil {
+		logrus.Fatalf("new sweep repository failed, err:%s", err.Error())
+	}
+
+	pipelineRepo, err := repositoryimpl.NewPipelineRepository(&cfg.Repository)
+	if err != nil {
+		logrus.Fatalf("new pipeline repository failed, err:%s", err.Error())
+	}
+
+	watchStateRepo, err := repositoryimpl.NewWatchStateRepository(&cfg.Repository)
+	if err != nil {
+		logrus.Fatalf("new watch state repository failed, err:%s", err.Error())
+	}
+
+	outboxRepo, err := repositoryimpl.NewCallbackOutboxRepository(&cfg.Repository)
+	if err != nil {
+		logrus.Fatalf("new callback outbox repository failed, err:%s", err.Error())
+	}
+
+	ledger, err := repositoryimpl.NewAdmissionLedgerRepository(&cfg.Repository)
+	if err != nil {
+		logrus.Fatalf("new admission ledger repository failed, err:%s", err.Error())
+	}
+
+	usageRepo, err := repositoryimpl.NewUsageRepository(&cfg.Repository)
+	if err != nil {
+		logrus.Fatalf("new usage repository failed, err:%s", err.Error())
+	}
+
+	sink, err := statussinkimpl.NewStatusSink(&cfg.StatusSink, log)
+	if err != nil {
+		logrus.Fatalf("new status sink failed, err:%s", err.Error())
+	}
+
 	// watch
-	ws, err := watchimpl.NewWatcher(&cfg.Watch, as)
+	ws, err := watchimpl.NewWatcher(
+		&cfg.Watch, as, sink, repo, watchStateRepo, outboxRepo, ledger, log,
+	)
 	if err != nil {
 		logrus.Errorf("new watch service failed, err:%s", err.Error())
 	}
 
-	service := app.NewAICCFinetuneService(as, ws, log)
+	service := app.NewAICCFinetuneService(as, ws, repo, log)
+	sweep := app.NewSweepService(service, ws, sweepRepo, repo, log)
+	pipeline := app.NewPipelineService(service, pipelineRepo, repo, log)
+	usage := app.NewUsageService(usageRepo, repo, log)
+
+	ws.RegisterDoneHandler(pipeline.OnFinetuneDone)
+	ws.RegisterDoneHandler(usage.OnFinetuneDone)
 	go ws.Run()
 
-	defer ws.Exit()
+	scheduler := app.NewFinetuneScheduler(
+		service, repo, time.Duration(cfg.Scheduler.Interval)*time.Second, log,
+	)
+	// submit the steps of pipelines failed to be submitted or missed
+	// when the finetunes they depend on were done.
+	scheduler.RegisterTask(pipeline.Resume)
+	go scheduler.Run()
+
+	// lead runs the work which only the leader replica does.
+	lead := func() {
+		ws.Lead()
+
+		if !cfg.Reconciler.Disable {
+			reconciler := app.NewJobReconciler(as, ws, repo, cfg.Reconciler.Policy, log)
+			if _, err := reconciler.Reconcile(); err != nil {
+				logrus.Errorf("reconcile jobs failed, err:%s", err.Error())
+			}
+		}
+
+		scheduler.Lead()
+
+		// record the usages of finetunes done before the last exit.
+		go usage.Backfill()
+	}
+
+	// stop submitting the scheduled finetunes before the watcher exits.
+	exit := []func(){scheduler.Exit, ws.Exit}
+
+	if e := &cfg.LeaderElection; e.Enable {
+		leaseRepo, err := repositoryimpl.NewLeaseRepository(&cfg.Repository)
+		if err != nil {
+			logrus.Fatalf("new lease repository failed, err:%s", err.Error())
+		}
+
+		elector := app.NewLeaderElector(
+			leaseRepo, e.Identity,
+			time.Duration(e.LeaseDuration)*time.Second,
+			time.Duration(e.RenewInterval)*time.Second,
+			log,
+		)
+
+		go elector.Run(lead, func() {
+			logrus.Error("lost the leadership, exit")
+
+			// exit gracefully, so the finetunes are handed off to the new leader.
+			if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+				logrus.Fatalf("exit failed, err:%s", err.Error())
+			}
+		})
+
+		// release the lease after the watcher handed off the finetunes.
+		exit = append(exit, elector.Exit)
+	} else {
+		lead()
+	}
 
 	server.StartWebServer(&server.Service{
+		Exit:     exit,
 		Log:      log,
 		Port:     o.service.Port,
 		Timeout:  o.service.GracePeriod,
 		Finetune: service,
+		Sweep:    sweep,
+		Pipeline: pipeline,
+		Callback: app.NewCallbackService(outboxRepo),
+		Usage:    usage,
 	})
 }
@@ -15,6 +15,16 @@ type AICCFinetuneCreateOption = controller.AICCFinetuneCreateRequest
 type DownloadURL = controller.AICCFinetuneResultResp
 type KeyValue = controller.AICCKeyValue
 type JobInfo = app.JobInfoDTO
+type JobPayload = app.JobPayloadDTO
+type AICCFinetune = app.AICCFinetuneDTO
+type InferenceOption = controller.AICCInferenceOption
+type ResumeOption = controller.AICCFinetuneResumeRequest
+type CloneOption = controller.AICCFinetuneCloneRequest
+type InferenceImage = app.InferenceImageDTO
+type SweepCreateOption = controller.SweepCreateRequest
+type Sweep = app.SweepDTO
+type PipelineCreateOption = controller.PipelineCreateRequest
+type Pipeline = app.PipelineDTO
 
 func NewAICCFinetuneCenter(endpoint string) AICCFinetuneCenter {
 	s := strings.TrimSuffix(endpoint, "/")
@@ -37,6 +47,14 @@ func (t AICCFinetuneCenter) jobURL(jobId string) string {
 	return fmt.Sprintf("%s/%s", t.endpoint, jobId)
 }
 
+func (t AICCFinetuneCenter) sweepURL() string {
+	return strings.TrimSuffix(t.endpoint, "/aiccfinetune") + "/sweep"
+}
+
+func (t AICCFinetuneCenter) pipelineURL() string {
+	return strings.TrimSuffix(t.endpoint, "/aiccfinetune") + "/pipeline"
+}
+
 func (t AICCFinetuneCenter) CreateAICCFinetune(opt *AICCFinetuneCreateOption) (
 	dto JobInfo, err error,
 ) {
@@ -58,6 +76,151 @@ func (t AICCFinetuneCenter) CreateAICCFinetune(opt *AICCFinetuneCreateOption) (
 	return *v, nil
 }
 
+func (t AICCFinetuneCenter) RenderAICCFinetune(opt *AICCFinetuneCreateOption) (
+	dto JobPayload, err error,
+) {
+	payload, err := utils.JsonMarshal(&opt)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPost, t.endpoint+"/render", bytes.NewBuffer(payload),
+	)
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &dto)
+
+	return
+}
+
+func (t AICCFinetuneCenter) ResumeAICCFinetune(finetuneId string, opt *ResumeOption) (
+	dto JobInfo, err error,
+) {
+	payload, err := utils.JsonMarshal(&opt)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPost, t.jobURL(finetuneId)+"/resume", bytes.NewBuffer(payload),
+	)
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &dto)
+
+	return
+}
+
+func (t AICCFinetuneCenter) CloneAICCFinetune(finetuneId string, opt *CloneOption) (
+	dto JobInfo, err error,
+) {
+	payload, err := utils.JsonMarshal(&opt)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPost, t.jobURL(finetuneId)+"/clone", bytes.NewBuffer(payload),
+	)
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &dto)
+
+	return
+}
+
+func (t AICCFinetuneCenter) GetAICCFinetune(finetuneId string) (r AICCFinetune, err error) {
+	req, err := http.NewRequest(http.MethodGet, t.jobURL(finetuneId), nil)
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &r)
+
+	return
+}
+
+func (t AICCFinetuneCenter) GetInferenceImages(finetuneId string) (r []InferenceImage, err error) {
+	req, err := http.NewRequest(http.MethodGet, t.jobURL(finetuneId)+"/images", nil)
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &r)
+
+	return
+}
+
+func (t AICCFinetuneCenter) CreateSweep(opt *SweepCreateOption) (r Sweep, err error) {
+	payload, err := utils.JsonMarshal(&opt)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.sweepURL(), bytes.NewBuffer(payload))
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &r)
+
+	return
+}
+
+func (t AICCFinetuneCenter) GetSweep(sweepId string) (r Sweep, err error) {
+	req, err := http.NewRequest(http.MethodGet, t.sweepURL()+"/"+sweepId, nil)
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &r)
+
+	return
+}
+
+func (t AICCFinetuneCenter) CreatePipeline(opt *PipelineCreateOption) (r Pipeline, err error) {
+	payload, err := utils.JsonMarshal(&opt)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.pipelineURL(), bytes.NewBuffer(payload))
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &r)
+
+	return
+}
+
+func (t AICCFinetuneCenter) GetPipeline(pipelineId string) (r Pipeline, err error) {
+	req, err := http.NewRequest(http.MethodGet, t.pipelineURL()+"/"+pipelineId, nil)
+	if err != nil {
+		return
+	}
+
+	err = t.forwardTo(req, &r)
+
+	return
+}
+
+func (t AICCFinetuneCenter) CancelPipeline(pipelineId string) error {
+	req, err := http.NewRequest(http.MethodPut, t.pipelineURL()+"/"+pipelineId, nil)
+	if err != nil {
+		return err
+	}
+
+	return t.forwardTo(req, nil)
+}
+
 func (t AICCFinetuneCenter) DeleteAICCFinetune(jobId string) error {
 	req, err := http.NewRequest(http.MethodDelete, t.jobURL(jobId), nil)
 	if err != nil {
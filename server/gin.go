@@ -21,9 +21,18 @@ type Service struct {
 	Port     int
 	Timeout  time.Duration
 	Finetune app.FinetuneService
+	Sweep    app.SweepService
+	Pipeline app.PipelineService
+	Callback app.CallbackService
+	Usage    app.UsageService
+
+	// Exit are called in order when the service is shutting down.
+	Exit []func()
 }
 
 func StartWebServer(service *Service) {
+	controller.Init(service.Log)
+
 	r := gin.New()
 	r.Use(gin.Recovery())
 	r.Use(logRequest())
@@ -35,6 +44,12 @@ func StartWebServer(service *Service) {
 		Handler: r,
 	}
 
+	interrupts.OnInterrupt(func() {
+		for _, f := range service.Exit {
+			f()
+		}
+	})
+
 	defer interrupts.WaitForGracefulShutdown()
 
 	interrupts.ListenAndServe(srv, service.Timeout)
@@ -52,6 +67,26 @@ func setRouter(engine *gin.Engine, service *Service) {
 			v1,
 			service.Finetune,
 		)
+
+		controller.AddRouterForSweepController(
+			v1,
+			service.Sweep,
+		)
+
+		controller.AddRouterForPipelineController(
+			v1,
+			service.Pipeline,
+		)
+
+		controller.AddRouterForCallbackController(
+			v1,
+			service.Callback,
+		)
+
+		controller.AddRouterForUsageController(
+			v1,
+			service.Usage,
+		)
 	}
 
 	engine.UseRawPath = true